@@ -6,9 +6,11 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"taskcli/internal/adapters/fsrepo"
 	"taskcli/internal/application"
+	"taskcli/internal/config"
 	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
 )
 
 const (
@@ -16,53 +18,149 @@ const (
 	ExitGeneralErr = 1
 	ExitUsage      = 2
 	ExitNotFound   = 3
+	ExitConflict   = 4
 )
 
+// commands are the names run dispatches itself; a workflow transition named like one could not be invoked
+var commands = []string{
+	"help", "add", "update", "delete", "restore", "project", "check", "trash", "parent", "block", "unblock",
+	"ready", "deps", "recur", "start", "stop", "log", "timesheet", "assign", "unassign", "estimate", "velocity",
+	"history", "comment", "show", "prioritize", "due", "tag", "set", "tags", "list", "archive", "unarchive",
+	"undo", "redo", "migrate", "move",
+}
+
 func main() {
 	os.Exit(run(os.Args))
 }
 
 func run(args []string) int {
+	cfg, rest, err := parseGlobalFlags(args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		printHelp(domain.DefaultWorkflow())
+		return ExitUsage
+	}
+	args = append([]string{args[0]}, rest...)
+
+	// the workflow decides the statuses and commands, so it is loaded before any help is printed
+	configPath := config.Locate(cfg.configPath)
+	settings, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return ExitGeneralErr
+	}
+	workflow := domain.DefaultWorkflow()
+	if w, ok := settings.DomainWorkflow(); ok {
+		if workflow, err = domain.NewWorkflow(w, commands...); err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid workflow in %s: %v\n", configPath, err)
+			return ExitGeneralErr
+		}
+	}
+	fields, err := domain.NewFieldSet(settings.DomainFields())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid fields in %s: %v\n", configPath, err)
+		return ExitGeneralErr
+	}
+
 	if len(args) < 2 {
-		printHelp()
+		printHelp(workflow)
 		return ExitUsage
 	}
 
-	repo, err := fsrepo.New("tasks.json")
+	be, err := openStore(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return ExitGeneralErr
 	}
-	svc := application.NewTaskService(repo)
+	defer be.close()
+
+	journal, err := openJournal(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return ExitGeneralErr
+	}
+	opts := []application.Option{
+		application.WithActor(settings.Actor()),
+		application.WithDefaultProject(settings.Project),
+		application.WithWorkflow(workflow),
+		application.WithFields(fields),
+		application.WithAutoDone(settings.AutoDone),
+		application.WithJournal(journal, strings.Join(args[1:], " ")),
+	}
+	if uses, writes := usesArchive(args[1:]); uses {
+		archive, err := openArchive(cfg, writes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return ExitGeneralErr
+		}
+		defer archive.close()
+		opts = append(opts, application.WithArchive(archive.store))
+	}
+	svc := application.NewTaskService(be.store, opts...)
 
 	switch args[1] {
 	case "help", "-h", "--help":
-		printHelp()
+		printHelp(workflow)
 		return ExitOk
 	case "add":
-		if len(args) < 3 {
-			usage(`add "description"`)
+		fs := newFlagSet("add")
+		priority := fs.String("priority", "", "low|medium|high|critical")
+		due := fs.String("due", "", "due date, e.g. 2025-03-31, tomorrow, +3d, next friday")
+		var tags stringsFlag
+		fs.Var(&tags, "tag", "tag to attach (repeatable)")
+		parent := fs.Int("parent", 0, "ID of the parent task")
+		var blockedBy stringsFlag
+		fs.Var(&blockedBy, "blocked-by", "ID of a task that must be done first (repeatable)")
+		recur := fs.String("recur", "", "daily|weekly|monthly|yearly or an RRULE")
+		project := fs.String("project", "", "project to add the task to")
+		estimate := fs.String("estimate", "", "story points like 3 or a duration like 4h")
+		pos, err := parseFlags(fs, args[2:])
+		if err != nil || len(pos) < 1 {
+			usage(`add "description" [--priority low|medium|high|critical] [--due date] [--tag name]... [--parent id] [--blocked-by id]... [--recur rule] [--project name] [--estimate 3|4h]`)
+			return ExitUsage
+		}
+
+		opts := application.AddOptions{Tags: tags, ParentID: *parent, Recurrence: *recur, Project: *project, Estimate: *estimate}
+		if opts.BlockedBy, err = parseIDs(blockedBy); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			return ExitUsage
 		}
-		desc := strings.Join(args[2:], " ")
-		t, err := svc.Add(desc)
+		if *priority != "" {
+			p, err := domain.ParsePriority(*priority)
+			if err != nil {
+				return handleError(err)
+			}
+			opts.Priority = p
+		}
+		if *due != "" {
+			if opts.Due, err = svc.ParseDate(*due); err != nil {
+				return handleError(err)
+			}
+		}
+
+		desc := strings.Join(pos, " ")
+		t, err := svc.AddWith(desc, opts)
 		if err != nil {
 			return handleError(err)
 		}
 		fmt.Printf("Task added successfully (ID: %d)\n", t.ID)
 		return ExitOk
 	case "update":
-		if len(args) < 4 {
-			usage(`update <id> "new description"`)
+		fs := newFlagSet("update")
+		rev := fs.Int("rev", 0, "only update if the task is still at this revision")
+		project := fs.String("project", "", "move the task to this project")
+		pos, err := parseFlags(fs, args[2:])
+		if err != nil || len(pos) < 1 || (len(pos) < 2 && *project == "") {
+			usage(`update <id> ["new description"] [--project name] [--rev N]`)
 			return ExitUsage
 		}
-		id, err := parseID(args[2])
+		id, err := parseID(pos[0])
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			return ExitUsage
 		}
-		desc := strings.Join(args[3:], " ")
-		if err := svc.Update(id, desc); err != nil {
+		opts := application.UpdateOptions{Description: strings.Join(pos[1:], " "), Project: *project, Revision: *rev}
+		if err := svc.UpdateWith(id, opts); err != nil {
 			return handleError(err)
 		}
 		fmt.Println("Task updated successfully")
@@ -80,28 +178,309 @@ func run(args []string) int {
 		if err := svc.Delete(id); err != nil {
 			return handleError(err)
 		}
-		fmt.Println("Task deleted successfully")
+		fmt.Printf("Task moved to the trash (undo with: task-cli restore %d)\n", id)
+		return ExitOk
+	case "restore":
+		if len(args) < 3 {
+			usage("restore <id>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		if err := svc.Restore(id); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task restored successfully")
+		return ExitOk
+	case "project":
+		return runProject(svc, args[2:])
+	case "check":
+		return runCheck(svc, args[2:])
+	case "trash":
+		return runTrash(svc, args[2:])
+	case "parent":
+		if len(args) < 4 {
+			usage("parent <id> <parent-id|none>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		var parentID int
+		if args[3] != "none" {
+			if parentID, err = parseID(args[3]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return ExitUsage
+			}
+		}
+		if err := svc.SetParent(id, parentID); err != nil {
+			return handleError(err)
+		}
+
+		if parentID == 0 {
+			fmt.Println("Task moved to the top level")
+		} else {
+			fmt.Printf("Task is now a subtask of %d\n", parentID)
+		}
+		return ExitOk
+	case "block", "unblock":
+		if len(args) < 4 {
+			usage(args[1] + " <id> <blocker-id>...")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		blockers, err := parseIDs(args[3:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		if args[1] == "block" {
+			err = svc.Block(id, blockers...)
+		} else {
+			err = svc.Unblock(id, blockers...)
+		}
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task dependencies updated")
+		return ExitOk
+	case "ready":
+		tasks, err := svc.Ready()
+		if err != nil {
+			return handleError(err)
+		}
+
+		now, color := time.Now(), useColor()
+		for _, t := range tasks {
+			fmt.Println(formatTask(workflow, t, now, color))
+		}
+		return ExitOk
+	case "deps":
+		if len(args) < 3 {
+			usage("deps <id>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		g, err := svc.Deps(id)
+		if err != nil {
+			return handleError(err)
+		}
+		printDeps(workflow, g, time.Now(), useColor())
+		return ExitOk
+	case "recur":
+		if len(args) < 4 {
+			usage("recur <id> <daily|weekly|monthly|yearly|RRULE|none>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		rule := args[3]
+		if rule == "none" {
+			rule = ""
+		}
+		if err := svc.Recur(id, rule); err != nil {
+			return handleError(err)
+		}
+
+		if rule == "" {
+			fmt.Println("Task no longer recurs")
+		} else {
+			fmt.Println("Task recurrence updated")
+		}
+		return ExitOk
+	case "start":
+		if len(args) < 3 {
+			usage("start <id>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		if err := svc.StartTimer(id); err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Timer started on task %d\n", id)
+		return ExitOk
+	case "stop":
+		t, elapsed, err := svc.StopTimer()
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Timer stopped on task %d after %s\n", t.ID, formatDuration(elapsed))
 		return ExitOk
-	case "mark-in-progress":
+	case "log":
+		if len(args) < 4 {
+			usage("log <id> <duration>   e.g. 1h30m, 45m, 1.5h")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		d, err := domain.ParseWorkDuration(args[3])
+		if err != nil {
+			return handleError(err)
+		}
+		if err := svc.LogTime(id, d); err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Logged %s on task %d\n", formatDuration(d), id)
+		return ExitOk
+	case "timesheet":
+		fs := newFlagSet("timesheet")
+		fs.Bool("week", true, "weekly report (the only report for now)")
+		of := fs.String("of", "today", "any day of the week to report")
+		if _, err := parseFlags(fs, args[2:]); err != nil {
+			usage("timesheet [--week] [--of date]")
+			return ExitUsage
+		}
+
+		date, err := svc.ParseDate(*of)
+		if err != nil {
+			return handleError(err)
+		}
+		day, err := time.ParseInLocation(domain.DateLayout, date, time.Local)
+		if err != nil {
+			return handleError(err)
+		}
+
+		sheet, err := svc.Timesheet(day)
+		if err != nil {
+			return handleError(err)
+		}
+		printTimesheet(sheet)
+		return ExitOk
+	case "assign", "unassign":
 		if len(args) < 3 {
-			usage("mark-in-progress <id>")
+			usage(args[1] + " <id> [user...]")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		users := args[3:]
+		if args[1] == "assign" {
+			if len(users) == 0 {
+				me, err := settings.CurrentUser()
+				if err != nil {
+					return handleError(err)
+				}
+				users = []string{me}
+			}
+			err = svc.Assign(id, users...)
+		} else {
+			err = svc.Unassign(id, users...)
+		}
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task assignees updated")
+		return ExitOk
+	case "estimate":
+		if len(args) < 4 {
+			usage("estimate <id> <points|duration|none>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		if err := svc.Estimate(id, args[3]); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task estimate updated")
+		return ExitOk
+	case "velocity":
+		fs := newFlagSet("velocity")
+		weeks := fs.Int("weeks", 8, "number of recent weeks to average over")
+		project := fs.String("project", "", "only tasks of this project")
+		if _, err := parseFlags(fs, args[2:]); err != nil {
+			usage("velocity [--weeks 8] [--project name]")
 			return ExitUsage
 		}
 
+		v, err := svc.Velocity(application.VelocityQuery{Weeks: *weeks, Project: *project})
+		if err != nil {
+			return handleError(err)
+		}
+		printVelocity(v)
+		return ExitOk
+	case "history":
+		if len(args) < 3 {
+			usage("history <id>")
+			return ExitUsage
+		}
 		id, err := parseID(args[2])
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			return ExitUsage
 		}
-		if err := svc.MarkInProgress(id); err != nil {
+
+		changes, err := svc.History(id)
+		if err != nil {
 			return handleError(err)
 		}
+		for _, c := range changes {
+			fmt.Println(formatChange(c))
+		}
+		return ExitOk
+	case "comment":
+		if len(args) < 3 {
+			usage(`comment <id> ["text"]`)
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
 
-		fmt.Println("Task marked as in progress")
+		text := strings.Join(args[3:], " ")
+		if text == "" {
+			task, err := svc.Get(id)
+			if err != nil {
+				return handleError(err)
+			}
+			template := fmt.Sprintf("\n# Comment on task %d: %s\n# Lines starting with '#' are ignored; an empty comment is not saved.\n", task.ID, task.Description)
+			if text, err = editText(template); err != nil {
+				return handleError(err)
+			}
+		}
+		if err := svc.Comment(id, text); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Comment added successfully")
 		return ExitOk
-	case "mark-done":
+	case "show":
 		if len(args) < 3 {
-			usage("mark-done <id>")
+			usage("show <id>")
 			return ExitUsage
 		}
 		id, err := parseID(args[2])
@@ -109,37 +488,507 @@ func run(args []string) int {
 			fmt.Fprintln(os.Stderr, err)
 			return ExitUsage
 		}
-		if err := svc.MarkDone(id); err != nil {
+		task, err := svc.Get(id)
+		if err != nil {
+			return handleError(err)
+		}
+		printTaskDetails(workflow, task, time.Now(), useColor())
+		return ExitOk
+	case "prioritize":
+		if len(args) < 4 {
+			usage("prioritize <id> low|medium|high|critical")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		p, err := domain.ParsePriority(args[3])
+		if err != nil {
+			return handleError(err)
+		}
+		if err := svc.Prioritize(id, p); err != nil {
 			return handleError(err)
 		}
-		fmt.Println("Task marked as done")
+		fmt.Printf("Task priority set to %s\n", p)
+		return ExitOk
+	case "due":
+		if len(args) < 4 {
+			usage("due <id> <date|none>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		input := strings.Join(args[3:], " ")
+		var due string
+		if input != "none" {
+			if due, err = svc.ParseDate(input); err != nil {
+				return handleError(err)
+			}
+		}
+		if err := svc.SetDue(id, due); err != nil {
+			return handleError(err)
+		}
+
+		if due == "" {
+			fmt.Println("Task due date cleared")
+		} else {
+			fmt.Printf("Task due on %s\n", due)
+		}
+		return ExitOk
+	case "tag":
+		if len(args) < 4 {
+			usage("tag <id> +add -remove ...")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+
+		var add, remove []string
+		for _, arg := range args[3:] {
+			switch {
+			case strings.HasPrefix(arg, "-"):
+				remove = append(remove, arg[1:])
+			default:
+				add = append(add, strings.TrimPrefix(arg, "+"))
+			}
+		}
+		if err := svc.Tag(id, add, remove); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task tags updated")
+		return ExitOk
+	case "set":
+		if len(args) < 4 {
+			usage("set <id> key=value ...")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		if err := svc.SetFields(id, args[3:]...); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task fields updated")
+		return ExitOk
+	case "tags":
+		counts, err := svc.Tags()
+		if err != nil {
+			return handleError(err)
+		}
+		for _, c := range counts {
+			fmt.Printf("%-20s %d\n", c.Tag, c.Count)
+		}
 		return ExitOk
 	case "list":
-		var filter *domain.TaskStatus
-		if len(args) >= 3 {
-			st, err := domain.ParseStatus(args[2])
+		fs := newFlagSet("list")
+		sortBy := fs.String("sort", "id", "id, priority or a custom field")
+		overdue := fs.Bool("overdue", false, "only overdue tasks")
+		dueBefore := fs.String("due-before", "", "only tasks due before this date")
+		var tags stringsFlag
+		fs.Var(&tags, "tag", "tag filter: name, a|b (or), !name (not); repeat for and")
+		tree := fs.Bool("tree", false, "show subtasks indented below their parent")
+		archived := fs.Bool("archived", false, "list the archive instead of the active tasks")
+		project := fs.String("project", "", "only tasks of this project")
+		var filters stringsFlag
+		fs.Var(&filters, "field", "custom field filter: key=value, key>=value, key, !key; repeat for and")
+		mine := fs.Bool("mine", false, "only tasks assigned to the current user")
+		assignee := fs.String("assignee", "", "only tasks assigned to this user; none for unassigned ones")
+		pos, err := parseFlags(fs, args[2:])
+		if err != nil || (*tree && *archived) || (*mine && *assignee != "") {
+			usage("list [" + statusChoices(workflow) + "] [--sort id|priority|field] [--overdue] [--due-before date] [--tag expr]... [--field expr]... [--project name] [--mine | --assignee user|none] [--tree | --archived]")
+			return ExitUsage
+		}
+
+		q := application.ListQuery{Project: *project, Assignee: *assignee}
+		if *mine {
+			if q.Assignee, err = settings.CurrentUser(); err != nil {
+				return handleError(err)
+			}
+		}
+		if len(pos) >= 1 {
+			st, err := domain.ParseStatus(workflow, pos[0])
+			if err != nil {
+				return handleError(err)
+			}
+			q.Status = &st
+		}
+		if q.Sort, err = application.ParseSortOrder(fields, *sortBy); err != nil {
+			return handleError(err)
+		}
+		q.Overdue = *overdue
+		if q.Tags, err = application.ParseTagFilter(tags); err != nil {
+			return handleError(err)
+		}
+		if q.Fields, err = application.ParseFieldFilter(fields, filters); err != nil {
+			return handleError(err)
+		}
+		if *dueBefore != "" {
+			if q.DueBefore, err = svc.ParseDate(*dueBefore); err != nil {
+				return handleError(err)
+			}
+		}
+
+		now, color := time.Now(), useColor()
+		if *tree {
+			nodes, err := svc.Tree(q)
 			if err != nil {
 				return handleError(err)
 			}
-			filter = &st
+			for _, n := range nodes {
+				fmt.Println(formatTreeNode(workflow, n, now, color))
+			}
+			return ExitOk
 		}
 
-		tasks, err := svc.List(filter)
+		list := svc.List
+		if *archived {
+			list = svc.ListArchived
+		}
+		tasks, err := list(q)
 		if err != nil {
 			return handleError(err)
 		}
 
 		for _, t := range tasks {
-			fmt.Printf("[%d] %-12s %s\n", t.ID, t.Status, t.Description)
+			fmt.Println(formatTask(workflow, t, now, color))
+		}
+		return ExitOk
+	case "archive":
+		fs := newFlagSet("archive")
+		done := fs.Bool("done", false, "archive done tasks")
+		cancelled := fs.Bool("cancelled", false, "archive cancelled tasks")
+		olderThan := fs.String("older-than", "", "time since the task was closed, e.g. 90d, 12w (0d for any)")
+		if _, err := parseFlags(fs, args[2:]); err != nil || *olderThan == "" || (!*done && !*cancelled) {
+			usage("archive --done [--cancelled] --older-than 90d")
+			return ExitUsage
+		}
+		age, err := domain.ParseAge(*olderThan)
+		if err != nil {
+			return handleError(err)
+		}
+
+		archived, err := svc.Archive(application.ArchiveQuery{Done: *done, Cancelled: *cancelled, OlderThan: age})
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Archived %d tasks\n", len(archived))
+		return ExitOk
+	case "unarchive":
+		if len(args) < 3 {
+			usage("unarchive <id>")
+			return ExitUsage
+		}
+		id, err := parseID(args[2])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		if err := svc.Unarchive(id); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Task unarchived successfully")
+		return ExitOk
+	case "undo":
+		fs := newFlagSet("undo")
+		list := fs.Bool("list", false, "show what can be undone and redone")
+		if _, err := parseFlags(fs, args[2:]); err != nil {
+			usage("undo [--list]")
+			return ExitUsage
+		}
+		if *list {
+			stacks, err := svc.Journal()
+			if err != nil {
+				return handleError(err)
+			}
+			printJournal(stacks)
+			return ExitOk
+		}
+
+		entry, err := svc.Undo()
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Undone: %s\n", entry.Command)
+		return ExitOk
+	case "redo":
+		entry, err := svc.Redo()
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Redone: %s\n", entry.Command)
+		return ExitOk
+	case "migrate":
+		fs := newFlagSet("migrate")
+		dryRun := fs.Bool("dry-run", false, "report pending migrations without writing")
+		if _, err := parseFlags(fs, args[2:]); err != nil {
+			usage("migrate [--dry-run]")
+			return ExitUsage
+		}
+		if be.migrator == nil {
+			fmt.Println("This store migrates itself automatically; nothing to do")
+			return ExitOk
+		}
+
+		report, err := be.migrator.Migrate(*dryRun)
+		if err != nil {
+			return handleError(err)
+		}
+		printMigrationReport(report)
+		return ExitOk
+	case "move":
+		fs := newFlagSet("move")
+		force := fs.Bool("force", false, "ignore open blockers or subtasks")
+		pos, err := parseFlags(fs, args[2:])
+		if err != nil || len(pos) != 2 {
+			usage("move <id> <" + statusChoices(workflow) + "> [--force]")
+			return ExitUsage
+		}
+		id, err := parseID(pos[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitUsage
+		}
+		status, err := domain.ParseStatus(workflow, pos[1])
+		if err != nil {
+			return handleError(err)
+		}
+
+		tr, next, err := svc.Move(id, status, *force)
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Println(tr.Message)
+		if next != nil {
+			fmt.Printf("Next occurrence added (ID: %d, due %s)\n", next.ID, next.Due)
 		}
 		return ExitOk
 	default:
+		if tr, ok := workflow.FindTransition(args[1]); ok {
+			return runTransition(svc, tr, args[2:])
+		}
+
 		fmt.Fprintf(os.Stderr, "unknown command %s\n", args[1])
-		printHelp()
+		printHelp(workflow)
 		return ExitUsage
 	}
 }
 
+// runTrash handles "trash list" and "trash purge --older-than age".
+func runTrash(svc *application.TaskService, args []string) int {
+	const trashUsage = "trash list | trash purge --older-than 30d"
+	if len(args) < 1 {
+		usage(trashUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		tasks, err := svc.Trash()
+		if err != nil {
+			return handleError(err)
+		}
+		if len(tasks) == 0 {
+			fmt.Println("The trash is empty")
+			return ExitOk
+		}
+		now := time.Now()
+		for _, t := range tasks {
+			fmt.Println(formatTrashed(svc.Workflow(), t, now))
+		}
+		return ExitOk
+	case "purge":
+		fs := newFlagSet("trash purge")
+		olderThan := fs.String("older-than", "", "age of the deletion, e.g. 30d, 2w, 12h (0d for everything)")
+		if _, err := parseFlags(fs, args[1:]); err != nil || *olderThan == "" {
+			usage("trash purge --older-than 30d")
+			return ExitUsage
+		}
+		age, err := domain.ParseAge(*olderThan)
+		if err != nil {
+			return handleError(err)
+		}
+
+		purged, err := svc.Purge(age)
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Purged %d tasks from the trash\n", len(purged))
+		return ExitOk
+	default:
+		usage(trashUsage)
+		return ExitUsage
+	}
+}
+
+func runCheck(svc *application.TaskService, args []string) int {
+	const checkUsage = `check add <id> "item" | check toggle <id> <n> | check rm <id> <n>`
+	if len(args) < 3 {
+		usage(checkUsage)
+		return ExitUsage
+	}
+	id, err := parseID(args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "add":
+		if err := svc.AddCheckItem(id, strings.Join(args[2:], " ")); err != nil {
+			return handleError(err)
+		}
+		fmt.Println("Checklist item added")
+		return ExitOk
+	case "toggle", "rm":
+		n, err := strconv.Atoi(args[2])
+		if err != nil || len(args) != 3 {
+			usage(checkUsage)
+			return ExitUsage
+		}
+		if args[0] == "rm" {
+			if err := svc.RemoveCheckItem(id, n); err != nil {
+				return handleError(err)
+			}
+			fmt.Println("Checklist item removed")
+			return ExitOk
+		}
+
+		item, completed, next, err := svc.ToggleCheckItem(id, n)
+		if err != nil {
+			return handleError(err)
+		}
+		state := "unchecked"
+		if item.Done {
+			state = "checked"
+		}
+		fmt.Printf("Checklist item %d %s\n", n, state)
+		if completed {
+			fmt.Println("Every item is checked: task marked as done")
+		}
+		if next != nil {
+			fmt.Printf("Next occurrence added (ID: %d, due %s)\n", next.ID, next.Due)
+		}
+		return ExitOk
+	default:
+		usage(checkUsage)
+		return ExitUsage
+	}
+}
+
+func runProject(svc *application.TaskService, args []string) int {
+	const projectUsage = "project create <name> | project list | project rename <old> <new> | project delete <name> [--cascade]"
+	if len(args) < 1 {
+		usage(projectUsage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) != 2 {
+			usage("project create <name>")
+			return ExitUsage
+		}
+		p, err := svc.CreateProject(args[1])
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Project %s created\n", p.Name)
+		return ExitOk
+	case "list":
+		projects, err := svc.Projects()
+		if err != nil {
+			return handleError(err)
+		}
+		for _, p := range projects {
+			fmt.Printf("%-20s %d open, %d total\n", p.Name, p.Open, p.Total)
+		}
+		return ExitOk
+	case "rename":
+		if len(args) != 3 {
+			usage("project rename <old> <new>")
+			return ExitUsage
+		}
+		if err := svc.RenameProject(args[1], args[2]); err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Project %s renamed to %s\n", args[1], args[2])
+		return ExitOk
+	case "delete":
+		fs := newFlagSet("project delete")
+		cascade := fs.Bool("cascade", false, "move the project's tasks to the trash")
+		pos, err := parseFlags(fs, args[1:])
+		if err != nil || len(pos) != 1 {
+			usage("project delete <name> [--cascade]")
+			return ExitUsage
+		}
+		trashed, err := svc.DeleteProject(pos[0], *cascade)
+		if err != nil {
+			return handleError(err)
+		}
+		fmt.Printf("Project %s deleted (%d tasks moved to the trash)\n", pos[0], len(trashed))
+		return ExitOk
+	default:
+		usage(projectUsage)
+		return ExitUsage
+	}
+}
+
+// runTransition handles the command of a workflow transition, e.g. "mark-done <id> [--force]".
+func runTransition(svc *application.TaskService, tr domain.Transition, args []string) int {
+	fs := newFlagSet(tr.Name)
+	force := fs.Bool("force", false, "ignore open blockers or subtasks")
+	pos, err := parseFlags(fs, args)
+	if err != nil || len(pos) < 1 {
+		usage(tr.Name + " <id> [--force]")
+		return ExitUsage
+	}
+	id, err := parseID(pos[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return ExitUsage
+	}
+
+	next, err := svc.Transition(id, tr.Name, *force)
+	if err != nil {
+		return handleError(err)
+	}
+
+	fmt.Println(tr.Message)
+	if next != nil {
+		fmt.Printf("Next occurrence added (ID: %d, due %s)\n", next.ID, next.Due)
+	}
+	return ExitOk
+}
+
+func printMigrationReport(r ports.MigrationReport) {
+	if len(r.Steps) == 0 {
+		fmt.Printf("%s is up to date (format v%d)\n", r.Path, r.ToVersion)
+		return
+	}
+
+	fmt.Printf("%s: format v%d -> v%d\n", r.Path, r.FromVersion, r.ToVersion)
+	for _, st := range r.Steps {
+		fmt.Printf("  v%d -> v%d: %s (%s)\n", st.From, st.From+1, st.Description, st.Changes)
+	}
+
+	if r.Applied {
+		fmt.Println("Migration complete")
+	} else {
+		fmt.Println("Dry run: no changes written")
+	}
+}
+
 func parseID(s string) (int, error) {
 	id, err := strconv.Atoi(s)
 	if err != nil || id <= 0 {
@@ -149,6 +998,19 @@ func parseID(s string) (int, error) {
 	return id, nil
 }
 
+func parseIDs(args []string) ([]int, error) {
+	ids := make([]int, 0, len(args))
+	for _, a := range args {
+		id, err := parseID(a)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
 func usage(example string) {
 	fmt.Fprintf(os.Stderr, "usage: task-tracker-cli-go %s\n", example)
 }
@@ -157,6 +1019,7 @@ func handleError(err error) int {
 	// Map domain errors to exit codes
 	var nf *domain.NotFoundError
 	var ve *domain.ValidationError
+	var ce *domain.ConflictError
 
 	switch {
 	case errors.As(err, &nf):
@@ -165,6 +1028,9 @@ func handleError(err error) int {
 	case errors.As(err, &ve):
 		fmt.Fprintln(os.Stderr, err.Error())
 		return ExitUsage
+	case errors.As(err, &ce):
+		fmt.Fprintln(os.Stderr, err.Error())
+		return ExitConflict
 	default:
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		return ExitGeneralErr
@@ -175,14 +1041,111 @@ const help = `Task Tracker CLI (Go)
 
 Usage:
 
-  task-cli add "description"
-  task-cli update <id> "new description"
-  task-cli delete <id>
-  task-cli mark-in-progress <id>
-  task-cli mark-done <id>
-  task-cli list [todo|in-progress|done]
+  task-cli [--store json|sqlite] [--file path] [--lock-timeout 5s] [--config path] <command> [args]
+
+  task-cli add "description" [--priority low|medium|high|critical] [--due date] [--tag name]...
+               [--parent id] [--blocked-by id]... [--recur rule] [--project name] [--estimate 3|4h]
+  task-cli update <id> ["new description"] [--project name] [--rev N]
+  task-cli delete <id>             moves the task to the trash
+  task-cli restore <id>
+  task-cli trash list
+  task-cli trash purge --older-than 30d
+  task-cli parent <id> <parent-id|none>
+  task-cli block <id> <blocker-id>...
+  task-cli unblock <id> <blocker-id>...
+  task-cli recur <id> <rule|none>
+  task-cli start <id>
+  task-cli stop
+  task-cli log <id> <duration>   e.g. 1h30m, 45m, 1.5h
+  task-cli timesheet [--week] [--of date]
+  task-cli assign <id> [user...]   without users, assigns the current user
+  task-cli unassign <id> [user...] without users, removes every assignee
+  task-cli estimate <id> <points|duration|none>   e.g. 3, 0.5, 4h, 1h30m
+  task-cli velocity [--weeks 8] [--project name]  estimate done per week and a forecast
+  task-cli history <id>
+  task-cli comment <id> ["text"]   without text, opens $VISUAL or $EDITOR
+  task-cli show <id>
+  task-cli move <id> <status> [--force]
+  task-cli ready
+  task-cli deps <id>
+  task-cli prioritize <id> low|medium|high|critical
+  task-cli due <id> <date|none>
+  task-cli tag <id> +add -remove ...
+  task-cli tags
+  task-cli set <id> key=value ...  custom fields declared in the settings; key= removes one
+  task-cli list [status] [--sort id|priority|<field>] [--overdue] [--due-before date]
+                [--tag expr]... [--field expr]... [--project name] [--mine | --assignee user|none]
+                [--tree | --archived]
+                tag expr: name, a|b (or), !name (not); repeat --tag for and
+                field expr: key=value, key!=value, key<value (also <=, >, >=), key (set), !key (unset)
+  task-cli check add <id> "item"
+  task-cli check toggle <id> <n>   items are numbered from 1 as in "show"
+  task-cli check rm <id> <n>
+  task-cli project create <name>
+  task-cli project list            every project with its task counts, the default (inbox) first
+  task-cli project rename <old> <new>
+  task-cli project delete <name> [--cascade]   --cascade moves its tasks to the trash
+  task-cli archive --done [--cancelled] --older-than 90d
+  task-cli unarchive <id>
+  task-cli undo [--list]
+  task-cli redo
+  task-cli migrate [--dry-run]
+
+%s
+Dates: YYYY-MM-DD, today, tomorrow, +3d, +2w, +1m, friday, next friday
+Recurrence: daily, weekly, monthly, yearly or an RRULE subset
+            (FREQ, INTERVAL, BYDAY, BYMONTHDAY, UNTIL), e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR
+
+Storage:
+
+  --store         json (default) or sqlite; env TASKCLI_STORE
+  --file          store path (default tasks.json / tasks.db); env TASKCLI_FILE
+  --lock-timeout  wait for concurrent invocations (default 5s); env TASKCLI_LOCK_TIMEOUT
+  --config        settings file (default ./.taskcli.json, then <user config dir>/taskcli/config.json);
+                  env TASKCLI_CONFIG
+
+Settings (JSON):
+
+  user            current user: recorded in task history and on comments, shown by list --mine
+                  (env TASKCLI_USER overrides it; default $USER)
+  project         project of new tasks added without --project (default inbox)
+  fields          custom fields, e.g. [{"name": "ticket"}, {"name": "estimate", "type": "number"}]
+                  types: string (default), number, date, bool
+  autoDone        true to mark a task done once its whole checklist is checked
+  workflow        custom statuses and transitions, e.g.
+                  {"statuses": [{"name": "backlog"}, {"name": "doing", "category": "active"},
+                                {"name": "shipped", "category": "done"}],
+                   "transitions": [{"name": "ship", "from": ["doing"], "to": "shipped"}]}
+                  categories: open (default), active, done, cancelled
 `
 
-func printHelp() {
-	fmt.Print(help)
+func printHelp(w domain.Workflow) {
+	fmt.Printf(help, workflowHelp(w))
+}
+
+func statusChoices(w domain.Workflow) string {
+	var names []string
+	for _, st := range w.StatusNames() {
+		names = append(names, string(st))
+	}
+	return strings.Join(names, "|")
+}
+
+// workflowHelp lists the statuses and the commands of the transition table of w.
+func workflowHelp(w domain.Workflow) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Statuses: %s\n\n", statusChoices(w))
+	b.WriteString("Workflow (--force ignores open blockers when starting and open subtasks when completing):\n\n")
+	for _, tr := range w.Transitions {
+		from := make([]string, len(tr.From))
+		for i, st := range tr.From {
+			from[i] = string(st)
+		}
+		cmd := tr.Name + " <id>"
+		if tr.Name == "" {
+			cmd = "move <id> " + string(tr.To)
+		}
+		fmt.Fprintf(&b, "  task-cli %-21s [--force]   %s -> %s\n", cmd, strings.Join(from, "|"), tr.To)
+	}
+	return b.String()
 }
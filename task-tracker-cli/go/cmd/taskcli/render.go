@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"taskcli/internal/application"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+)
+
+const (
+	ansiRed   = "\033[31m"
+	ansiReset = "\033[0m"
+)
+
+// useColor reports whether stdout is a terminal that accepts ANSI colors (see https://no-color.org).
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// formatTask renders one line of list output; overdue tasks are marked and, on a terminal, shown in red.
+func formatTask(w domain.Workflow, t domain.Task, now time.Time, color bool) string {
+	line := fmt.Sprintf("[%d] %-12s %-9s %s", t.ID, t.Status, priorityLabel(t.Priority), t.Description)
+	if t.Project != "" {
+		line += " @" + t.Project
+	}
+	if done, total := t.ChecklistProgress(); total > 0 {
+		line += fmt.Sprintf(" ☑ %d/%d", done, total)
+	}
+	if t.Estimate != "" {
+		line += " ~" + t.Estimate
+	}
+	if len(t.Tags) > 0 {
+		line += " #" + strings.Join(t.Tags, " #")
+	}
+	if t.Recurrence != "" {
+		line += fmt.Sprintf(" (repeats %s)", t.Recurrence)
+	}
+	if len(t.Assignees) > 0 {
+		line += fmt.Sprintf(" (assigned to %s)", strings.Join(t.Assignees, ", "))
+	}
+	if _, running := t.ActiveTimer(); running {
+		line += " (timer running)"
+	}
+	if !w.Declares(t.Status) {
+		line += " (status not in the workflow, see move)"
+	}
+
+	switch {
+	case t.IsOverdue(w, now):
+		line += fmt.Sprintf(" (OVERDUE, due %s)", t.Due)
+		if color {
+			line = ansiRed + line + ansiReset
+		}
+	case t.Due != "":
+		line += fmt.Sprintf(" (due %s)", t.Due)
+	}
+
+	return line
+}
+
+// formatTrashed renders a line of the trash with the local time the task was deleted.
+func formatTrashed(w domain.Workflow, t domain.Task, now time.Time) string {
+	return fmt.Sprintf("%s (deleted %s)", formatTask(w, t, now, false), localTime(t.DeletedAt))
+}
+
+// formatTreeNode indents a task by its depth and appends the rolled-up completion of its subtasks.
+func formatTreeNode(w domain.Workflow, n application.TreeNode, now time.Time, color bool) string {
+	line := strings.Repeat("  ", n.Depth) + formatTask(w, n.Task, now, color)
+	if pct := n.Percent(); pct >= 0 {
+		line += fmt.Sprintf(" [%d/%d %d%%]", n.Done, n.Total, pct)
+	}
+	return line
+}
+
+// printDeps shows what a task waits for and what waits for it, indented by distance.
+func printDeps(w domain.Workflow, g application.DepGraph, now time.Time, color bool) {
+	fmt.Println(formatTask(w, g.Task, now, color))
+
+	sections := []struct {
+		title string
+		nodes []application.DepNode
+	}{
+		{"Blocked by:", g.BlockedBy},
+		{"Blocks:", g.Blocks},
+	}
+	for _, sec := range sections {
+		if len(sec.nodes) == 0 {
+			continue
+		}
+		fmt.Println(sec.title)
+		for _, n := range sec.nodes {
+			line := strings.Repeat("  ", n.Depth+1) + formatTask(w, n.Task, now, color)
+			if n.Repeat {
+				line += " (see above)"
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// printTimesheet renders the week as a table of tasks by day with row and column totals.
+func printTimesheet(sheet application.Timesheet) {
+	const descWidth = 30
+
+	fmt.Printf("Week of %s\n\n", sheet.Days[0].Format(domain.DateLayout))
+	fmt.Printf("%-6s %-*s", "ID", descWidth, "Task")
+	for _, d := range sheet.Days {
+		fmt.Printf(" %7s", d.Format("Mon 02"))
+	}
+	fmt.Printf(" %8s\n", "Total")
+
+	for _, r := range sheet.Rows {
+		desc := r.Task.Description
+		if runes := []rune(desc); len(runes) > descWidth {
+			desc = string(runes[:descWidth-1]) + "…"
+		}
+		fmt.Printf("%-6s %-*s", fmt.Sprintf("[%d]", r.Task.ID), descWidth, desc)
+		for _, d := range r.Days {
+			fmt.Printf(" %7s", formatDuration(d))
+		}
+		fmt.Printf(" %8s\n", formatDuration(r.Total))
+	}
+
+	fmt.Printf("%-6s %-*s", "", descWidth, "Total")
+	for _, d := range sheet.DayTotals {
+		fmt.Printf(" %7s", formatDuration(d))
+	}
+	fmt.Printf(" %8s\n", formatDuration(sheet.Total))
+}
+
+// printVelocity renders the completed estimate per week, its average and the forecast of the open work.
+func printVelocity(v application.Velocity) {
+	fmt.Printf("%-10s %8s %8s %6s\n", "Week of", "Points", "Hours", "Tasks")
+	for _, w := range v.Weeks {
+		fmt.Printf("%-10s %8s %8s %6d\n", w.Start.Format(domain.DateLayout), formatPoints(w.Done.Points), formatDuration(w.Done.Duration), w.Tasks)
+	}
+	fmt.Printf("%-10s %8s %8s\n\n", "Average", formatPoints(v.Average.Points), formatDuration(v.Average.Duration))
+
+	remaining := v.Remaining.String()
+	if remaining == "" {
+		remaining = "nothing"
+	}
+	fmt.Printf("Remaining: %s", remaining)
+	if v.Unestimated > 0 {
+		fmt.Printf(" (%d open tasks without estimate)", v.Unestimated)
+	}
+	fmt.Println()
+
+	for _, f := range []struct {
+		unit            string
+		remaining, pace bool
+		done            time.Time
+	}{
+		{"points", v.Remaining.Points > 0, v.Average.Points > 0, v.PointsDone},
+		{"hours", v.Remaining.Duration > 0, v.Average.Duration > 0, v.HoursDone},
+	} {
+		switch {
+		case !f.remaining:
+		case !f.pace:
+			fmt.Printf("Forecast (%s): none, nothing was completed yet\n", f.unit)
+		case f.done.IsZero():
+			fmt.Printf("Forecast (%s): none, more than a century away at the average pace\n", f.unit)
+		default:
+			fmt.Printf("Forecast (%s): done by %s at the average pace\n", f.unit, f.done.Format(domain.DateLayout))
+		}
+	}
+}
+
+func formatPoints(p float64) string {
+	if p == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(math.Round(p*10)/10, 'f', -1, 64)
+}
+
+// formatDuration renders whole minutes as 1h30m, 45m or 2h; zero is shown as a dash.
+func formatDuration(d time.Duration) string {
+	m := int(d.Round(time.Minute).Minutes())
+	switch {
+	case d == 0:
+		return "-"
+	case m < 60:
+		return fmt.Sprintf("%dm", m)
+	case m%60 == 0:
+		return fmt.Sprintf("%dh", m/60)
+	default:
+		return fmt.Sprintf("%dh%02dm", m/60, m%60)
+	}
+}
+
+// formatChange renders one history entry in local time.
+func formatChange(c domain.Change) string {
+	what := c.New
+	if c.Field != domain.FieldCreated {
+		what = fmt.Sprintf("%s -> %s", orNone(c.Old), orNone(c.New))
+	}
+	return fmt.Sprintf("%s  %-12s %-12s %s", localTime(c.At), c.Actor, c.Field, what)
+}
+
+// printJournal lists the commands that can be undone and redone, the next one first.
+func printJournal(stacks ports.JournalStacks) {
+	sections := []struct {
+		title   string
+		entries []ports.JournalEntry
+	}{
+		{"Undo:", stacks.Undo},
+		{"Redo:", stacks.Redo},
+	}
+	for _, sec := range sections {
+		fmt.Println(sec.title)
+		if len(sec.entries) == 0 {
+			fmt.Println("  (nothing)")
+		}
+		for i := len(sec.entries) - 1; i >= 0; i-- {
+			e := sec.entries[i]
+			if e.Barrier {
+				fmt.Printf("  %s  %-30s cannot be undone\n", localTime(e.At), e.Command)
+				continue
+			}
+			tasks := make([]string, len(e.Changes))
+			for j, c := range e.Changes {
+				tasks[j] = strconv.Itoa(c.ID)
+			}
+			fmt.Printf("  %s  %-30s tasks %s\n", localTime(e.At), e.Command, strings.Join(tasks, ", "))
+		}
+	}
+}
+
+// printTaskDetails shows every field of a task followed by its comments and history.
+func printTaskDetails(w domain.Workflow, t domain.Task, now time.Time, color bool) {
+	fmt.Printf("Task %d: %s\n\n", t.ID, t.Description)
+
+	due := orNone(t.Due)
+	if t.IsOverdue(w, now) {
+		due += " (OVERDUE)"
+		if color {
+			due = ansiRed + due + ansiReset
+		}
+	}
+	tags := "(none)"
+	if len(t.Tags) > 0 {
+		tags = "#" + strings.Join(t.Tags, " #")
+	}
+	tracked := "-"
+	if d, err := t.TrackedTime(now); err == nil {
+		tracked = formatDuration(d)
+	}
+	if _, running := t.ActiveTimer(); running {
+		tracked += " (timer running)"
+	}
+	blockers := make([]string, len(t.BlockedBy))
+	for i, b := range t.BlockedBy {
+		blockers[i] = strconv.Itoa(b)
+	}
+
+	fields := []struct{ name, value string }{
+		{"Project", t.ProjectName()},
+		{"Status", string(t.Status)},
+		{"Priority", string(priorityLabel(t.Priority))},
+		{"Due", due},
+		{"Tags", tags},
+		{"Parent", orNone(optionalID(t.ParentID))},
+		{"Blocked by", orNone(strings.Join(blockers, ", "))},
+		{"Recurrence", orNone(t.Recurrence)},
+		{"Estimate", orNone(t.Estimate)},
+		{"Assignees", orNone(strings.Join(t.Assignees, ", "))},
+		{"Tracked", tracked},
+		{"Created", localTime(t.CreatedAt)},
+		{"Updated", localTime(t.UpdatedAt)},
+		{"Revision", strconv.Itoa(t.Revision)},
+	}
+	for _, name := range t.FieldNames() {
+		fields = append(fields, struct{ name, value string }{name, t.Fields[name]})
+	}
+	for _, f := range fields {
+		fmt.Printf("  %-12s %s\n", f.name+":", f.value)
+	}
+
+	if len(t.Checklist) > 0 {
+		done, total := t.ChecklistProgress()
+		fmt.Printf("\nChecklist (%d/%d):\n", done, total)
+		for i, item := range t.Checklist {
+			mark := " "
+			if item.Done {
+				mark = "x"
+			}
+			fmt.Printf("  %d. [%s] %s\n", i+1, mark, item.Text)
+		}
+	}
+
+	fmt.Printf("\nComments (%d):\n", len(t.Comments))
+	for _, c := range t.Comments {
+		fmt.Printf("  %s  %s\n", localTime(c.At), c.Author)
+		for _, line := range strings.Split(c.Text, "\n") {
+			fmt.Println("    " + line)
+		}
+	}
+
+	fmt.Println("\nHistory:")
+	for _, c := range t.History {
+		fmt.Println("  " + formatChange(c))
+	}
+}
+
+// localTime renders an RFC3339 timestamp in local time, or as stored if it cannot be parsed.
+func localTime(ts string) string {
+	if t, err := time.Parse(time.RFC3339, ts); err == nil {
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	return ts
+}
+
+func optionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func orNone(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	return v
+}
+
+// priorityLabel renders tasks stored before priorities existed as medium.
+func priorityLabel(p domain.Priority) domain.Priority {
+	if p == "" {
+		return domain.PriorityMedium
+	}
+	return p
+}
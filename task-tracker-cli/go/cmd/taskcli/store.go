@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"taskcli/internal/adapters/collection"
+	"taskcli/internal/adapters/fsrepo"
+	"taskcli/internal/adapters/sqliterepo"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+)
+
+const (
+	storeJSON   = "json"
+	storeSQLite = "sqlite"
+)
+
+// storeConfig selects the persistence adapter and the settings file.
+// Values come from global flags, falling back to TASKCLI_STORE / TASKCLI_FILE / TASKCLI_LOCK_TIMEOUT / TASKCLI_CONFIG.
+type storeConfig struct {
+	kind        string
+	path        string
+	lockTimeout time.Duration
+	configPath  string // empty to look up the default locations (see config.Locate)
+}
+
+// parseGlobalFlags consumes flags placed before the command name
+// and returns the remaining arguments starting at the command.
+func parseGlobalFlags(args []string) (storeConfig, []string, error) {
+	cfg := storeConfig{
+		kind:        envOr("TASKCLI_STORE", storeJSON),
+		path:        os.Getenv("TASKCLI_FILE"),
+		lockTimeout: fsrepo.DefaultLockTimeout,
+		configPath:  os.Getenv("TASKCLI_CONFIG"),
+	}
+	if v := os.Getenv("TASKCLI_LOCK_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, nil, fmt.Errorf("invalid TASKCLI_LOCK_TIMEOUT: %w", err)
+		}
+		cfg.lockTimeout = d
+	}
+
+	fs := flag.NewFlagSet("task-tracker-cli-go", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cfg.kind, "store", cfg.kind, "storage backend: json|sqlite")
+	fs.StringVar(&cfg.path, "file", cfg.path, "path to the task store")
+	fs.DurationVar(&cfg.lockTimeout, "lock-timeout", cfg.lockTimeout, "how long to wait for other invocations")
+	fs.StringVar(&cfg.configPath, "config", cfg.configPath, "path to the settings file")
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// backend is the opened persistence adapter.
+type backend struct {
+	store    ports.TaskStore
+	migrator ports.Migrator // nil when the store upgrades itself
+	close    func()
+}
+
+// openStore builds the task store selected by cfg.
+// The returned close func must be called once the command finishes.
+func openStore(cfg storeConfig) (backend, error) {
+	switch cfg.kind {
+	case storeJSON:
+		repo, err := fsrepo.New(pathOr(cfg.path, defaultPath(storeJSON)), fsrepo.WithLockTimeout(cfg.lockTimeout))
+		if err != nil {
+			return backend{}, err
+		}
+		return backend{store: collection.New(repo), migrator: repo, close: func() {}}, nil
+	case storeSQLite:
+		// SQLite applies its schema migrations when opened
+		repo, err := sqliterepo.New(pathOr(cfg.path, defaultPath(storeSQLite)), sqliterepo.WithLockTimeout(cfg.lockTimeout))
+		if err != nil {
+			return backend{}, err
+		}
+		return backend{store: repo, close: func() { _ = repo.Close() }}, nil
+	default:
+		return backend{}, fmt.Errorf("unknown store %q (expected: json|sqlite)", cfg.kind)
+	}
+}
+
+// openArchive opens the store that archived tasks are moved to. It sits next to the active store,
+// e.g. tasks.archive.json for tasks.json, and is only opened by the commands that use it.
+// Commands that only read it get an empty stand-in while it does not exist, rather than creating it.
+func openArchive(cfg storeConfig, writes bool) (backend, error) {
+	path := pathOr(cfg.path, defaultPath(cfg.kind))
+	cfg.path = siblingPath(path, ".archive"+filepath.Ext(path))
+	if _, err := os.Stat(cfg.path); !writes && errors.Is(err, os.ErrNotExist) {
+		return backend{store: collection.New(missingArchive{}), close: func() {}}, nil
+	}
+	return openStore(cfg)
+}
+
+// missingArchive is an archive that was never written: it holds no tasks and cannot be written either.
+type missingArchive struct{}
+
+func (missingArchive) Load() ([]domain.Task, error) { return []domain.Task{}, nil }
+
+func (missingArchive) Save([]domain.Task) error {
+	return errors.New("the archive does not exist and this command does not create it")
+}
+
+// openJournal opens the undo journal next to the store selected by cfg, e.g. tasks.db.journal.json for tasks.db.
+// It is named after the whole file name so that stores differing only in their extension never share one.
+func openJournal(cfg storeConfig) (*fsrepo.Journal, error) {
+	path := pathOr(cfg.path, defaultPath(cfg.kind))
+	return fsrepo.NewJournal(path+".journal.json", fsrepo.WithLockTimeout(cfg.lockTimeout))
+}
+
+// siblingPath replaces the extension of path with suffix.
+func siblingPath(path, suffix string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + suffix
+}
+
+// usesArchive reports whether the command line needs the archive store, and whether it writes to it.
+func usesArchive(args []string) (uses, writes bool) {
+	switch args[0] {
+	case "archive", "unarchive":
+		return true, true
+	case "velocity":
+		return true, false
+	case "project":
+		// renaming a project moves its archived tasks as well
+		return len(args) > 1 && args[1] == "rename", true
+	case "list":
+		for _, a := range args[1:] {
+			if a == "--archived" || a == "-archived" || strings.HasPrefix(a, "--archived=") {
+				return true, false
+			}
+		}
+	}
+	return false, false
+}
+
+func defaultPath(kind string) string {
+	if kind == storeSQLite {
+		return "tasks.db"
+	}
+	return "tasks.json"
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func pathOr(path, fallback string) string {
+	if path != "" {
+		return path
+	}
+	return fallback
+}
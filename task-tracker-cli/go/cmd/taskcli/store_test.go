@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun_ProjectRenameMovesArchivedTasks(t *testing.T) {
+	for _, kind := range []string{storeJSON, storeSQLite} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			settings := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(settings, []byte("{}"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg := storeConfig{kind: kind, path: filepath.Join(dir, defaultPath(kind))}
+			taskcli := func(args ...string) {
+				t.Helper()
+				args = append([]string{"taskcli", "--store", kind, "--file", cfg.path, "--config", settings}, args...)
+				if code := run(args); code != ExitOk {
+					t.Fatalf("%v: exit code %d", args[7:], code)
+				}
+			}
+
+			taskcli("project", "create", "api")
+			taskcli("add", "Ship v1", "--project", "api")
+			taskcli("mark-done", "1")
+			taskcli("archive", "--done", "--older-than", "0d")
+			taskcli("project", "rename", "api", "web")
+
+			archive, err := openArchive(cfg, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer archive.close()
+			task, err := archive.store.Get(1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if task.Project != "web" {
+				t.Errorf("expected the archived task in project web, got %q", task.Project)
+			}
+		})
+	}
+}
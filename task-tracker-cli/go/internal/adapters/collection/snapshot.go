@@ -0,0 +1,152 @@
+package collection
+
+import (
+	"fmt"
+	"sort"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+)
+
+// snapshot is an in-memory TaskStore over a loaded collection.
+// It backs a single unit of work; Store saves it back once the work succeeds.
+// Tasks are handed out as deep copies, so that callers mutating them cannot alter the stored state behind its back.
+
+var _ ports.TaskStore = (*snapshot)(nil)
+
+type snapshot struct {
+	tasks    []domain.Task
+	lastID   int
+	projects []domain.Project
+	dirty    bool
+}
+
+func (s *snapshot) Get(id int) (domain.Task, error) {
+	idx := s.indexOf(id)
+	if idx == -1 {
+		return domain.Task{}, &domain.NotFoundError{Msg: "task not found"}
+	}
+
+	return s.tasks[idx].Clone(), nil
+}
+
+func (s *snapshot) List() ([]domain.Task, error) {
+	res := make([]domain.Task, len(s.tasks))
+	for i, t := range s.tasks {
+		res[i] = t.Clone()
+	}
+	return res, nil
+}
+
+func (s *snapshot) ListByStatus(status domain.TaskStatus) ([]domain.Task, error) {
+	res := make([]domain.Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		if t.Status == status {
+			res = append(res, t.Clone())
+		}
+	}
+
+	return res, nil
+}
+
+func (s *snapshot) Insert(task domain.Task) error {
+	if s.indexOf(task.ID) != -1 {
+		return fmt.Errorf("task %d already exists", task.ID)
+	}
+
+	s.tasks = append(s.tasks, task)
+	s.dirty = true
+	return nil
+}
+
+func (s *snapshot) Update(task domain.Task) error {
+	idx := s.indexOf(task.ID)
+	if idx == -1 {
+		return &domain.NotFoundError{Msg: "task not found"}
+	}
+
+	if stored := s.tasks[idx].Revision; stored != task.Revision {
+		return domain.NewConflictError(task.ID, task.Revision, stored)
+	}
+
+	task.Revision++
+	s.tasks[idx] = task
+	s.dirty = true
+	return nil
+}
+
+func (s *snapshot) Delete(id int) error {
+	idx := s.indexOf(id)
+	if idx == -1 {
+		return &domain.NotFoundError{Msg: "task not found"}
+	}
+
+	s.tasks = append(s.tasks[:idx], s.tasks[idx+1:]...)
+	s.dirty = true
+	return nil
+}
+
+// NextID hands out the ID after the highest one ever allocated or stored.
+func (s *snapshot) NextID() (int, error) {
+	for _, t := range s.tasks {
+		if t.ID > s.lastID {
+			s.lastID = t.ID
+		}
+	}
+
+	s.lastID++
+	s.dirty = true
+	return s.lastID, nil
+}
+
+func (s *snapshot) Projects() ([]domain.Project, error) {
+	return append([]domain.Project{}, s.projects...), nil
+}
+
+func (s *snapshot) SaveProject(p domain.Project) error {
+	idx := s.projectIndex(p.Name)
+	if idx == -1 {
+		s.projects = append(s.projects, p)
+		sort.Slice(s.projects, func(i, j int) bool { return s.projects[i].Name < s.projects[j].Name })
+	} else {
+		s.projects[idx] = p
+	}
+
+	s.dirty = true
+	return nil
+}
+
+func (s *snapshot) DeleteProject(name string) error {
+	idx := s.projectIndex(name)
+	if idx == -1 {
+		return &domain.NotFoundError{Msg: "project not found"}
+	}
+
+	s.projects = append(s.projects[:idx], s.projects[idx+1:]...)
+	s.dirty = true
+	return nil
+}
+
+// Atomic runs fn directly: a snapshot already is a unit of work.
+func (s *snapshot) Atomic(fn func(tx ports.TaskStore) error) error {
+	return fn(s)
+}
+
+func (s *snapshot) indexOf(id int) int {
+	for i := range s.tasks {
+		if s.tasks[i].ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (s *snapshot) projectIndex(name string) int {
+	for i := range s.projects {
+		if s.projects[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
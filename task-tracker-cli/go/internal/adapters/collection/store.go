@@ -0,0 +1,133 @@
+// Package collection adapts whole-collection repositories to the per-task TaskStore port.
+package collection
+
+import (
+	"errors"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+)
+
+// Store implements TaskStore on top of any TaskRepository (e.g. fsrepo.Repo).
+// Each unit of work loads the full collection once, applies changes in memory and saves it back.
+// When the repository is also a ports.Locker the whole cycle runs under its lock,
+// so concurrent processes cannot interleave and lose each other's updates.
+// When it is a ports.Sequencer the ID counter is persisted with the tasks;
+// otherwise IDs are derived from the stored tasks and a deleted highest ID may come back.
+// Named projects are only persisted when it is a ports.ProjectCatalog; otherwise creating one fails.
+
+var _ ports.TaskStore = (*Store)(nil)
+
+var errNoProjects = errors.New("this store cannot persist projects")
+
+type Store struct{ repo ports.TaskRepository }
+
+// New wraps repo so it can be used where a TaskStore is expected.
+func New(repo ports.TaskRepository) *Store {
+	return &Store{repo: repo}
+}
+
+func (s *Store) Get(id int) (task domain.Task, err error) {
+	err = s.Atomic(func(tx ports.TaskStore) error {
+		task, err = tx.Get(id)
+		return err
+	})
+	return task, err
+}
+
+func (s *Store) List() (tasks []domain.Task, err error) {
+	err = s.Atomic(func(tx ports.TaskStore) error {
+		tasks, err = tx.List()
+		return err
+	})
+	return tasks, err
+}
+
+func (s *Store) ListByStatus(status domain.TaskStatus) (tasks []domain.Task, err error) {
+	err = s.Atomic(func(tx ports.TaskStore) error {
+		tasks, err = tx.ListByStatus(status)
+		return err
+	})
+	return tasks, err
+}
+
+func (s *Store) Insert(task domain.Task) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.Insert(task) })
+}
+
+func (s *Store) Update(task domain.Task) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.Update(task) })
+}
+
+func (s *Store) Delete(id int) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.Delete(id) })
+}
+
+func (s *Store) NextID() (id int, err error) {
+	err = s.Atomic(func(tx ports.TaskStore) error {
+		id, err = tx.NextID()
+		return err
+	})
+	return id, err
+}
+
+func (s *Store) Projects() (projects []domain.Project, err error) {
+	err = s.Atomic(func(tx ports.TaskStore) error {
+		projects, err = tx.Projects()
+		return err
+	})
+	return projects, err
+}
+
+func (s *Store) SaveProject(p domain.Project) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.SaveProject(p) })
+}
+
+func (s *Store) DeleteProject(name string) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.DeleteProject(name) })
+}
+
+// Atomic loads the collection, runs fn against it and saves it back if fn changed anything.
+func (s *Store) Atomic(fn func(tx ports.TaskStore) error) error {
+	return s.withLock(func() error {
+		tasks, err := s.repo.Load()
+		if err != nil {
+			return err
+		}
+
+		seq, _ := s.repo.(ports.Sequencer)
+		catalog, _ := s.repo.(ports.ProjectCatalog)
+
+		snap := &snapshot{tasks: tasks}
+		if seq != nil {
+			snap.lastID = seq.LastID()
+		}
+		if catalog != nil {
+			snap.projects = catalog.Projects()
+		}
+
+		if err := fn(snap); err != nil {
+			return err
+		}
+		if !snap.dirty {
+			return nil
+		}
+
+		if seq != nil {
+			seq.SetLastID(snap.lastID)
+		}
+		if catalog != nil {
+			catalog.SetProjects(snap.projects)
+		} else if len(snap.projects) > 0 {
+			return errNoProjects
+		}
+		return s.repo.Save(snap.tasks)
+	})
+}
+
+func (s *Store) withLock(fn func() error) error {
+	if l, ok := s.repo.(ports.Locker); ok {
+		return l.WithLock(fn)
+	}
+
+	return fn()
+}
@@ -0,0 +1,93 @@
+package collection
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"taskcli/internal/adapters/fsrepo"
+	"taskcli/internal/adapters/repotest"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"testing"
+)
+
+func TestStoreContract(t *testing.T) {
+	repotest.RunStore(t, func(t *testing.T, path string) ports.TaskStore {
+		repo, err := fsrepo.New(path)
+		if err != nil {
+			t.Fatalf("failed to create repo: %v", err)
+		}
+		return New(repo)
+	})
+}
+
+func TestAtomic_ConcurrentWritersDoNotLoseUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Separate handles behave like separate taskcli processes
+			repo, err := fsrepo.New(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			errs <- New(repo).Atomic(func(tx ports.TaskStore) error {
+				tasks, err := tx.List()
+				if err != nil {
+					return err
+				}
+				return tx.Insert(domain.Task{ID: len(tasks) + 1, Description: "task", Status: domain.StatusTodo})
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	repo, _ := fsrepo.New(path)
+	tasks, err := repo.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(tasks) != writers {
+		t.Fatalf("expected %d tasks, got %d", writers, len(tasks))
+	}
+}
+
+func TestAtomic_FailedWorkIsNotSaved(t *testing.T) {
+	repo, err := fsrepo.New(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	store := New(repo)
+
+	boom := errors.New("boom")
+	err = store.Atomic(func(tx ports.TaskStore) error {
+		if err := tx.Insert(domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo}); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+
+	tasks, _ := store.List()
+	if len(tasks) != 0 {
+		t.Fatalf("expected nothing saved, got %+v", tasks)
+	}
+}
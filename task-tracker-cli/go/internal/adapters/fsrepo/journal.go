@@ -0,0 +1,73 @@
+package fsrepo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"taskcli/internal/ports"
+	"time"
+)
+
+var _ ports.Journal = (*Journal)(nil)
+
+// Journal implements ports.Journal as a JSON file, written atomically under its own sidecar lock like Repo.
+// The file is only created by the first Update.
+type Journal struct {
+	path        string
+	lockTimeout time.Duration
+}
+
+// NewJournal creates a journal stored at filename, relative to the working directory if not absolute.
+func NewJournal(filename string, opts ...Option) (*Journal, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options are shared with Repo
+	r := &Repo{lockTimeout: DefaultLockTimeout}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return &Journal{path: abs, lockTimeout: r.lockTimeout}, nil
+}
+
+func (j *Journal) Load() (ports.JournalStacks, error) {
+	var stacks ports.JournalStacks
+
+	b, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return stacks, nil
+	}
+	if err != nil {
+		return stacks, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return stacks, nil
+	}
+
+	if err := json.Unmarshal(b, &stacks); err != nil {
+		return ports.JournalStacks{}, fmt.Errorf("corrupted JSON is in %s: %w", j.path, err)
+	}
+	return stacks, nil
+}
+
+func (j *Journal) Update(fn func(stacks *ports.JournalStacks) error) error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return err
+	}
+
+	return withFileLock(j.path, j.lockTimeout, func() error {
+		stacks, err := j.Load()
+		if err != nil {
+			return err
+		}
+		if err := fn(&stacks); err != nil {
+			return err
+		}
+		return writeJSON(j.path, stacks)
+	})
+}
@@ -0,0 +1,61 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"taskcli/internal/ports"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.journal.json")
+	journal, err := NewJournal(path)
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+
+	stacks, err := journal.Load()
+	if err != nil || len(stacks.Undo) != 0 || len(stacks.Redo) != 0 {
+		t.Fatalf("expected empty stacks, got %+v, %v", stacks, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no file before the first update, got %v", err)
+	}
+
+	entry := ports.JournalEntry{
+		Command: "mark-done 1",
+		At:      "2025-03-12T09:00:00Z",
+		Changes: []ports.TaskChange{{
+			ID:     1,
+			Before: &ports.TaskState{Digest: "d1", Fields: map[string]json.RawMessage{"status": json.RawMessage(`"todo"`)}},
+			After:  &ports.TaskState{Digest: "d2", Fields: map[string]json.RawMessage{"status": json.RawMessage(`"done"`)}},
+		}},
+	}
+	err = journal.Update(func(stacks *ports.JournalStacks) error {
+		stacks.Undo = append(stacks.Undo, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	failed := errors.New("boom")
+	err = journal.Update(func(stacks *ports.JournalStacks) error {
+		stacks.Undo = nil
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+
+	stacks, err = journal.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !reflect.DeepEqual(stacks.Undo, []ports.JournalEntry{entry}) {
+		t.Errorf("expected the entry to survive a failed update, got %+v", stacks.Undo)
+	}
+}
@@ -0,0 +1,51 @@
+package fsrepo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLockTimeout is returned when another process holds the store lock for longer than the configured timeout.
+var ErrLockTimeout = errors.New("task store is locked by another process")
+
+// DefaultLockTimeout is how long WithLock waits for a concurrent invocation to finish.
+const DefaultLockTimeout = 5 * time.Second
+
+// lockPollInterval is how often a busy lock is retried.
+const lockPollInterval = 20 * time.Millisecond
+
+// WithLock runs fn while holding an exclusive advisory lock on a sidecar "<file>.lock".
+// It waits up to the configured timeout and fails with ErrLockTimeout otherwise.
+// The lock is not reentrant: fn must not call WithLock again.
+func (r *Repo) WithLock(fn func() error) error {
+	return withFileLock(r.path, r.lockTimeout, fn)
+}
+
+// withFileLock holds the sidecar lock of path while fn runs.
+func withFileLock(path string, timeout time.Duration, fn func() error) error {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			return err
+		}
+		if ok {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w (%s, waited %s)", ErrLockTimeout, f.Name(), timeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+	defer func() { _ = unlock(f) }()
+
+	return fn()
+}
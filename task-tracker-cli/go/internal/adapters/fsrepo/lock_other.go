@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package fsrepo
+
+import "os"
+
+// Platforms without file locking fall back to running unlocked.
+func tryLock(f *os.File) (bool, error) { return true, nil }
+
+func unlock(f *os.File) error { return nil }
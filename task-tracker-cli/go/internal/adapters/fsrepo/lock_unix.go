@@ -0,0 +1,23 @@
+//go:build unix
+
+package fsrepo
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock attempts a non-blocking flock; ok is false when another process holds it.
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
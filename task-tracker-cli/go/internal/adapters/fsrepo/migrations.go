@@ -0,0 +1,116 @@
+package fsrepo
+
+import (
+	"fmt"
+	"taskcli/internal/ports"
+)
+
+// Format versions of tasks.json:
+//
+//	1: bare JSON array of tasks (legacy, still readable)
+//	2: {"version": 2, "tasks": [...]}
+//	3: every task has a revision
+//	4: "lastId" records the highest ID ever allocated
+//	5: every task has a priority
+const (
+	legacyVersion  = 1
+	CurrentVersion = 5
+)
+
+// document is a decoded tasks.json before it is mapped onto domain types,
+// so migrations can reshape data that no longer fits the current structs.
+type document map[string]any
+
+// migration upgrades a document from version from to from+1 and summarizes what it changed.
+type migration struct {
+	from        int
+	description string
+	apply       func(doc document) (string, error)
+}
+
+// migrations must stay ordered and contiguous; append new steps and bump CurrentVersion.
+var migrations = []migration{
+	{
+		from:        1,
+		description: "wrap the task array in a versioned envelope",
+		apply: func(doc document) (string, error) {
+			// The reader already lifted the legacy array into doc["tasks"]
+			return fmt.Sprintf("%d tasks moved under \"tasks\"", len(taskList(doc))), nil
+		},
+	},
+	{
+		from:        2,
+		description: "initialize task revisions",
+		apply: func(doc document) (string, error) {
+			n := 0
+			for _, t := range taskList(doc) {
+				if rev, _ := t["revision"].(float64); rev < 1 {
+					t["revision"] = 1
+					n++
+				}
+			}
+			return fmt.Sprintf("revision set on %d tasks", n), nil
+		},
+	},
+	{
+		from:        3,
+		description: "record the ID sequence",
+		apply: func(doc document) (string, error) {
+			last := 0.0
+			for _, t := range taskList(doc) {
+				if id, _ := t["id"].(float64); id > last {
+					last = id
+				}
+			}
+			doc["lastId"] = last
+			return fmt.Sprintf("lastId set to %d", int(last)), nil
+		},
+	},
+	{
+		from:        4,
+		description: "default task priorities to medium",
+		apply: func(doc document) (string, error) {
+			n := 0
+			for _, t := range taskList(doc) {
+				if p, _ := t["priority"].(string); p == "" {
+					t["priority"] = "medium"
+					n++
+				}
+			}
+			return fmt.Sprintf("priority set on %d tasks", n), nil
+		},
+	},
+}
+
+// upgrade applies every pending migration to doc and records the steps taken.
+func upgrade(doc document, from int) ([]ports.MigrationStep, error) {
+	var steps []ports.MigrationStep
+	for _, m := range migrations {
+		if m.from < from {
+			continue
+		}
+
+		changes, err := m.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("migrate v%d -> v%d: %w", m.from, m.from+1, err)
+		}
+		steps = append(steps, ports.MigrationStep{From: m.from, Description: m.description, Changes: changes})
+	}
+	doc["version"] = CurrentVersion
+
+	return steps, nil
+}
+
+// taskList returns the raw task objects of doc.
+func taskList(doc document) []map[string]any {
+	raw, _ := doc["tasks"].([]any)
+
+	tasks := make([]map[string]any, 0, len(raw))
+	for _, item := range raw {
+		if t, ok := item.(map[string]any); ok {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return tasks
+}
@@ -0,0 +1,128 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const legacyFile = `[
+ {"id": 2, "description": "Write docs", "status": "done", "createdAt": "2025-12-23T11:14:18Z", "updatedAt": "2025-12-23T13:48:43Z"},
+ {"id": 3, "description": "Take notes", "status": "todo", "createdAt": "2025-12-23T13:55:58Z", "updatedAt": "2025-12-23T13:55:58Z"}
+]`
+
+func newRepoWithContent(t *testing.T, content string) (*Repo, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	repo, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	return repo, path
+}
+
+func TestLoad_LegacyArrayIsUpgradedInMemory(t *testing.T) {
+	repo, path := newRepoWithContent(t, legacyFile)
+
+	tasks, err := repo.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if len(tasks) != 2 || tasks[0].ID != 2 || tasks[1].Description != "Take notes" {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+	for _, task := range tasks {
+		if task.Revision != 1 {
+			t.Errorf("task %d: expected revision 1, got %d", task.ID, task.Revision)
+		}
+	}
+
+	b, _ := os.ReadFile(path)
+	if string(b) != legacyFile {
+		t.Errorf("load must not rewrite the file")
+	}
+}
+
+func TestSave_WritesVersionedEnvelope(t *testing.T) {
+	repo, path := newRepoWithContent(t, legacyFile)
+
+	tasks, _ := repo.Load()
+	if err := repo.Save(tasks); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	var env envelope
+	b, _ := os.ReadFile(path)
+	if err := json.Unmarshal(b, &env); err != nil {
+		t.Fatalf("expected envelope, got %s: %v", b, err)
+	}
+	if env.Version != CurrentVersion || len(env.Tasks) != 2 {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+}
+
+func TestMigrate_DryRunReportsWithoutWriting(t *testing.T) {
+	repo, path := newRepoWithContent(t, legacyFile)
+
+	report, err := repo.Migrate(true)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	if report.FromVersion != legacyVersion || report.ToVersion != CurrentVersion {
+		t.Errorf("expected v%d -> v%d, got %+v", legacyVersion, CurrentVersion, report)
+	}
+	if len(report.Steps) != CurrentVersion-legacyVersion {
+		t.Errorf("expected %d steps, got %+v", CurrentVersion-legacyVersion, report.Steps)
+	}
+	if report.Applied {
+		t.Errorf("dry run must not apply")
+	}
+
+	b, _ := os.ReadFile(path)
+	if string(b) != legacyFile {
+		t.Errorf("dry run must not rewrite the file")
+	}
+}
+
+func TestMigrate_UpgradesFileOnce(t *testing.T) {
+	repo, path := newRepoWithContent(t, legacyFile)
+
+	report, err := repo.Migrate(false)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if !report.Applied {
+		t.Fatalf("expected migration to be applied")
+	}
+
+	b, _ := os.ReadFile(path)
+	if !strings.HasPrefix(strings.TrimSpace(string(b)), "{") {
+		t.Fatalf("expected envelope on disk, got %s", b)
+	}
+
+	again, err := repo.Migrate(false)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if len(again.Steps) != 0 || again.Applied {
+		t.Errorf("expected up-to-date file to need no steps, got %+v", again)
+	}
+}
+
+func TestLoad_NewerVersionIsRejected(t *testing.T) {
+	repo, _ := newRepoWithContent(t, `{"version": 999, "tasks": []}`)
+
+	if _, err := repo.Load(); err == nil {
+		t.Fatalf("expected error for a file written by a newer version")
+	}
+}
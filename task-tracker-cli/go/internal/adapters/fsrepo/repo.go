@@ -1,6 +1,7 @@
 package fsrepo
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,18 +10,41 @@ import (
 	"path/filepath"
 	"taskcli/internal/domain"
 	"taskcli/internal/ports"
+	"time"
 )
 
 // Repo implements TaskRepository against a JSON file on disk.
 // It uses only the standard library (os, io, encoding/json) and performs atomic writes using os.CreateTemp followed by os.Rename.
+// The file is a versioned envelope (see migrations.go); legacy bare arrays are still read.
 
-var _ ports.TaskRepository = (*Repo)(nil)
+var (
+	_ ports.TaskRepository = (*Repo)(nil)
+	_ ports.Locker         = (*Repo)(nil)
+	_ ports.Migrator       = (*Repo)(nil)
+	_ ports.Sequencer      = (*Repo)(nil)
+	_ ports.ProjectCatalog = (*Repo)(nil)
+)
+
+type Repo struct {
+	path        string
+	lockTimeout time.Duration
+	lastID      int              // ID sequence as of the last Load, written back by Save
+	projects    []domain.Project // named projects as of the last Load, written back by Save
+	hasProjects bool             // projects came from Load or SetProjects; otherwise Save keeps the persisted ones
+	loaded      bool             // lastID and projects were read from the file; otherwise Save reads them first
+}
+
+// Option customizes a Repo.
+type Option func(*Repo)
 
-type Repo struct{ path string }
+// WithLockTimeout sets how long WithLock waits before giving up.
+func WithLockTimeout(d time.Duration) Option {
+	return func(r *Repo) { r.lockTimeout = d }
+}
 
 // New creates a file-backed repo at filename
 // filename is interpreted relative to the current working directory if not absolute
-func New(filename string) (*Repo, error) {
+func New(filename string, opts ...Option) (*Repo, error) {
 	if filename == "" {
 		filename = "task.json"
 	}
@@ -34,29 +58,240 @@ func New(filename string) (*Repo, error) {
 		return nil, err
 	}
 
-	return &Repo{path: abs}, nil
+	r := &Repo{path: abs, lockTimeout: DefaultLockTimeout}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
 }
 
 func (r *Repo) Load() ([]domain.Task, error) {
-	f, err := os.Open(r.path)
+	b, err := r.read()
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
-	b, err := io.ReadAll(f)
+	// Allow empty file to represent no tasks
+	if len(bytes.TrimSpace(b)) == 0 {
+		return []domain.Task{}, r.adopt(document{})
+	}
+
+	doc, version, err := r.decode(b)
 	if err != nil {
 		return nil, err
 	}
 
-	// Allow empty file to represent no tasks
-	if len(b) == 0 {
-		return []domain.Task{}, nil
+	// Older files are upgraded in memory; they are rewritten on the next Save or by Migrate
+	if version < CurrentVersion {
+		if _, err := upgrade(doc, version); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks, err := tasksOf(doc, r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.adopt(doc); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// adopt keeps the ID sequence and the named projects of doc for the next Save to write back.
+func (r *Repo) adopt(doc document) error {
+	projects, err := projectsOf(doc, r.path)
+	if err != nil {
+		return err
+	}
+
+	last, _ := doc["lastId"].(float64)
+	r.lastID = int(last)
+	r.projects = projects
+	r.hasProjects = true
+	r.loaded = true
+	return nil
+}
+
+// Save writes tasks along with the ID sequence, which never moves backwards, and the named projects.
+// Both are kept as of the last Load, which should run under the same WithLock; a Save without a prior Load
+// reads them from the file first so that it loses neither.
+func (r *Repo) Save(tasks []domain.Task) error {
+	if tasks == nil {
+		tasks = []domain.Task{}
+	}
+
+	if !r.loaded {
+		lastID, projects, err := r.persisted()
+		if err != nil {
+			return err
+		}
+		r.SetLastID(lastID)
+		if !r.hasProjects {
+			r.SetProjects(projects)
+		}
+	}
+
+	for _, t := range tasks {
+		if t.ID > r.lastID {
+			r.lastID = t.ID
+		}
+	}
+
+	return r.write(envelope{Version: CurrentVersion, LastID: r.lastID, Projects: r.projects, Tasks: tasks})
+}
+
+// LastID returns the highest ID ever allocated as of the last Load or Save.
+func (r *Repo) LastID() int { return r.lastID }
+
+// SetLastID records the ID sequence to persist on the next Save.
+func (r *Repo) SetLastID(id int) {
+	if id > r.lastID {
+		r.lastID = id
+	}
+}
+
+// Projects returns the named projects as of the last Load or Save.
+func (r *Repo) Projects() []domain.Project {
+	return append([]domain.Project(nil), r.projects...)
+}
+
+// SetProjects records the named projects to persist on the next Save.
+func (r *Repo) SetProjects(projects []domain.Project) {
+	r.projects = append([]domain.Project(nil), projects...)
+	r.hasProjects = true
+}
+
+// persisted reads the ID sequence and the named projects currently in the file.
+func (r *Repo) persisted() (int, []domain.Project, error) {
+	b, err := r.read()
+	if err != nil || len(bytes.TrimSpace(b)) == 0 {
+		return 0, nil, err
+	}
+
+	doc, version, err := r.decode(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if version < CurrentVersion {
+		if _, err := upgrade(doc, version); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	last, _ := doc["lastId"].(float64)
+	projects, err := projectsOf(doc, r.path)
+	return int(last), projects, err
+}
+
+// Migrate upgrades the file to CurrentVersion step by step.
+// With dryRun set the file is left untouched and the report lists the pending steps.
+func (r *Repo) Migrate(dryRun bool) (ports.MigrationReport, error) {
+	report := ports.MigrationReport{Path: r.path, FromVersion: CurrentVersion, ToVersion: CurrentVersion}
+
+	err := r.WithLock(func() error {
+		b, err := r.read()
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(b)) == 0 {
+			return nil
+		}
+
+		doc, version, err := r.decode(b)
+		if err != nil {
+			return err
+		}
+		report.FromVersion = version
+		if version == CurrentVersion {
+			return nil
+		}
+
+		report.Steps, err = upgrade(doc, version)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return nil
+		}
+
+		tasks, err := tasksOf(doc, r.path)
+		if err != nil {
+			return err
+		}
+		if err := r.adopt(doc); err != nil {
+			return err
+		}
+		if err := r.Save(tasks); err != nil {
+			return err
+		}
+		report.Applied = true
+		return nil
+	})
+
+	return report, err
+}
+
+// envelope is the on-disk layout written by Save.
+type envelope struct {
+	Version  int              `json:"version"`
+	LastID   int              `json:"lastId"`
+	Projects []domain.Project `json:"projects,omitempty"`
+	Tasks    []domain.Task    `json:"tasks"`
+}
+
+func (r *Repo) read() ([]byte, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// decode parses the file into a generic document and reports its format version.
+// A bare array is the legacy (version 1) format.
+func (r *Repo) decode(b []byte) (document, int, error) {
+	b = bytes.TrimSpace(b)
+
+	if b[0] == '[' {
+		var tasks []any
+		if err := json.Unmarshal(b, &tasks); err != nil {
+			return nil, 0, fmt.Errorf("corrupted JSON is in %s: %w", r.path, err)
+		}
+		return document{"tasks": tasks}, legacyVersion, nil
+	}
+
+	var doc document
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, 0, fmt.Errorf("corrupted JSON is in %s: %w", r.path, err)
+	}
+
+	version, ok := doc["version"].(float64)
+	if !ok || version < legacyVersion {
+		return nil, 0, fmt.Errorf("corrupted JSON is in %s: missing or invalid version", r.path)
+	}
+	if int(version) > CurrentVersion {
+		return nil, 0, fmt.Errorf("%s uses format version %d, newer than supported version %d", r.path, int(version), CurrentVersion)
+	}
+
+	return doc, int(version), nil
+}
+
+// tasksOf maps the tasks of an up-to-date document onto domain types.
+func tasksOf(doc document, path string) ([]domain.Task, error) {
+	b, err := json.Marshal(doc["tasks"])
+	if err != nil {
+		return nil, err
 	}
 
 	var tasks []domain.Task
 	if err := json.Unmarshal(b, &tasks); err != nil {
-		return nil, fmt.Errorf("corrupted JSON is in %s: %w", r.path, err)
+		return nil, fmt.Errorf("corrupted JSON is in %s: %w", path, err)
 	}
 
 	if tasks == nil {
@@ -66,8 +301,33 @@ func (r *Repo) Load() ([]domain.Task, error) {
 	return tasks, nil
 }
 
-func (r *Repo) Save(tasks []domain.Task) error {
-	dir := filepath.Dir(r.path)
+// projectsOf decodes the named projects; documents written before projects existed have none.
+func projectsOf(doc document, path string) ([]domain.Project, error) {
+	raw, ok := doc["projects"]
+	if !ok {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []domain.Project
+	if err := json.Unmarshal(b, &projects); err != nil {
+		return nil, fmt.Errorf("corrupted JSON is in %s: %w", path, err)
+	}
+	return projects, nil
+}
+
+// write atomically replaces the file with v encoded as JSON.
+func (r *Repo) write(v any) error {
+	return writeJSON(r.path, v)
+}
+
+// writeJSON atomically replaces the file at path with v encoded as JSON.
+func writeJSON(path string, v any) error {
+	dir := filepath.Dir(path)
 
 	tmp, err := os.CreateTemp(dir, "task-*.json")
 	if err != nil {
@@ -85,7 +345,7 @@ func (r *Repo) Save(tasks []domain.Task) error {
 	enc := json.NewEncoder(tmp)
 	enc.SetIndent("", " ")
 
-	if err := enc.Encode(tasks); err != nil {
+	if err := enc.Encode(v); err != nil {
 		return cleanup(err)
 	}
 
@@ -100,9 +360,9 @@ func (r *Repo) Save(tasks []domain.Task) error {
 
 	// On Windows, os.Rename fails if destination exists
 	// Best-effort remove the old file first
-	_ = os.Remove(r.path)
+	_ = os.Remove(path)
 
-	if err := os.Rename(tmpPath, r.path); err != nil {
+	if err := os.Rename(tmpPath, path); err != nil {
 		return err
 	}
 
@@ -1,11 +1,26 @@
 package fsrepo
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
+	"taskcli/internal/adapters/repotest"
 	"taskcli/internal/domain"
+	"taskcli/internal/ports"
 	"testing"
+	"time"
 )
 
+func TestRepoContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, path string) ports.TaskRepository {
+		repo, err := New(path)
+		if err != nil {
+			t.Fatalf("failed to create repo: %v", err)
+		}
+		return repo
+	})
+}
+
 func TestRepoSaveAndLoad(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "tasks.json")
@@ -50,3 +65,70 @@ func TestRepoEmptyFile(t *testing.T) {
 		t.Fatalf("expected empty tasks")
 	}
 }
+
+func TestRepoSave_KeepsWhatLoadRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte(`{"version": 5, "lastId": 7, "tasks": [{"id": 2, "description": "A", "status": "todo", "priority": "medium"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	tasks, err := repo.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	// the save belongs to the same read-modify-write cycle, so the file is not read again
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(tasks); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	if _, err := repo.Load(); err != nil || repo.LastID() != 7 {
+		t.Fatalf("expected the sequence at 7, got %d (%v)", repo.LastID(), err)
+	}
+}
+
+func TestWithLock_TimesOutWhileHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	holder, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	waiter, err := New(path, WithLockTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	err = holder.WithLock(func() error {
+		return waiter.WithLock(func() error {
+			t.Fatal("lock acquired while held by another handle")
+			return nil
+		})
+	})
+
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout, got %v", err)
+	}
+}
+
+func TestWithLock_ReleasedAfterUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	repo, err := New(path, WithLockTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.WithLock(func() error { return nil }); err != nil {
+			t.Fatalf("attempt %d: unexpected error: %v", i+1, err)
+		}
+	}
+}
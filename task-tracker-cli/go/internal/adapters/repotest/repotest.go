@@ -0,0 +1,435 @@
+// Package repotest holds the behavioral contracts every TaskRepository and TaskStore adapter must satisfy.
+// Adapter packages call Run from their own tests so they are all held to the same expectations.
+package repotest
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"testing"
+)
+
+// Factory opens a repository backed by the file at path.
+// Calling it twice with the same path must reopen the same store.
+type Factory func(t *testing.T, path string) ports.TaskRepository
+
+// Run executes the repository contract against repositories produced by open.
+func Run(t *testing.T, open Factory) {
+	t.Run("EmptyStore", func(t *testing.T) {
+		repo := open(t, newPath(t))
+
+		tasks, err := repo.Load()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tasks == nil || len(tasks) != 0 {
+			t.Fatalf("expected empty non-nil tasks, got %#v", tasks)
+		}
+	})
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		repo := open(t, newPath(t))
+
+		want := []domain.Task{
+			{ID: 1, Description: "Buy tomato", Status: domain.StatusTodo, CreatedAt: "2025-01-01T10:00:00Z", UpdatedAt: "2025-01-01T10:00:00Z"},
+			{
+				ID: 2, Description: "Cook dinner", Status: domain.StatusInProgress,
+				CreatedAt: "2025-01-02T10:00:00Z", UpdatedAt: "2025-01-03T10:00:00Z", Revision: 3,
+				Priority: domain.PriorityHigh, Due: "2025-01-10", Tags: []string{"backend", "urgent"},
+				ParentID: 1, BlockedBy: []int{1}, Recurrence: "monthly", SeriesID: 1, AnchorDay: 31,
+				TimeEntries: []domain.TimeEntry{
+					{Start: "2025-01-03T09:00:00Z", End: "2025-01-03T10:30:00Z"},
+					{Start: "2025-01-04T09:00:00Z"},
+				},
+				History: []domain.Change{
+					{At: "2025-01-02T10:00:00Z", Actor: "alice", Field: domain.FieldCreated, New: "Cook dinner"},
+					{At: "2025-01-03T10:00:00Z", Actor: "bob", Field: "status", Old: "todo", New: "in-progress"},
+				},
+				DeletedAt: "2025-01-05T10:00:00Z",
+				Comments:  []domain.Comment{{At: "2025-01-03T11:00:00Z", Author: "alice", Text: "Use the big pot"}},
+				Project:   "home",
+				Fields:    map[string]string{"ticket": "JIRA-123", "estimate": "3"},
+				Checklist: []domain.CheckItem{{Text: "Chop onions", Done: true}, {Text: "Boil water"}},
+				Estimate:  "1h30m",
+				Assignees: []string{"alice", "bob"},
+			},
+		}
+		if err := repo.Save(want); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		got, err := repo.Load()
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		assertTasks(t, got, want)
+	})
+
+	t.Run("SaveReplacesCollection", func(t *testing.T) {
+		repo := open(t, newPath(t))
+
+		first := []domain.Task{
+			{ID: 1, Description: "A", Status: domain.StatusTodo},
+			{ID: 2, Description: "B", Status: domain.StatusTodo},
+		}
+		if err := repo.Save(first); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		second := []domain.Task{
+			{ID: 2, Description: "B updated", Status: domain.StatusDone},
+		}
+		if err := repo.Save(second); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		got, err := repo.Load()
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		assertTasks(t, got, second)
+	})
+
+	t.Run("PersistsAcrossReopen", func(t *testing.T) {
+		path := newPath(t)
+		want := []domain.Task{{ID: 7, Description: "Persist me", Status: domain.StatusDone}}
+
+		if err := open(t, path).Save(want); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		got, err := open(t, path).Load()
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		assertTasks(t, got, want)
+	})
+
+	t.Run("SaveWithoutLoadKeepsSequenceAndProjects", func(t *testing.T) {
+		path := newPath(t)
+		repo := open(t, path)
+		seq, isSequencer := repo.(ports.Sequencer)
+		catalog, isCatalog := repo.(ports.ProjectCatalog)
+		if !isSequencer || !isCatalog {
+			t.Skip("the repository keeps no ID sequence or projects next to the tasks")
+		}
+
+		projects := []domain.Project{{Name: "api", CreatedAt: "2025-01-01T10:00:00Z"}}
+		seq.SetLastID(9)
+		catalog.SetProjects(projects)
+		if err := repo.Save([]domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo}}); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		// A fresh handle that never loaded must not forget them
+		want := []domain.Task{{ID: 1, Description: "A2", Status: domain.StatusDone}}
+		if err := open(t, path).Save(want); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		reopened := open(t, path)
+		got, err := reopened.Load()
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		assertTasks(t, got, want)
+		if last := reopened.(ports.Sequencer).LastID(); last != 9 {
+			t.Errorf("expected the ID sequence to stay at 9, got %d", last)
+		}
+		if got := reopened.(ports.ProjectCatalog).Projects(); !reflect.DeepEqual(got, projects) {
+			t.Errorf("expected %+v, got %+v", projects, got)
+		}
+	})
+}
+
+func newPath(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "tasks")
+}
+
+func assertTasks(t *testing.T, got, want []domain.Task) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d tasks, got %d: %+v", len(want), len(got), got)
+	}
+
+	byID := make(map[int]domain.Task, len(got))
+	for _, task := range got {
+		byID[task.ID] = task
+	}
+
+	for _, w := range want {
+		g, ok := byID[w.ID]
+		if !ok {
+			t.Fatalf("task %d missing from %+v", w.ID, got)
+		}
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("task %d: expected %+v, got %+v", w.ID, w, g)
+		}
+	}
+}
+
+// StoreFactory opens a per-task store backed by the file at path.
+type StoreFactory func(t *testing.T, path string) ports.TaskStore
+
+// RunStore executes the TaskStore contract against stores produced by open.
+func RunStore(t *testing.T, open StoreFactory) {
+	t.Run("InsertAndGet", func(t *testing.T) {
+		store := open(t, newPath(t))
+
+		want := domain.Task{ID: 1, Description: "Buy tomato", Status: domain.StatusTodo, CreatedAt: "2025-01-01T10:00:00Z", UpdatedAt: "2025-01-01T10:00:00Z"}
+		if err := store.Insert(want); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+
+		got, err := store.Get(1)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("InsertDuplicate", func(t *testing.T) {
+		store := open(t, newPath(t))
+
+		task := domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo}
+		if err := store.Insert(task); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+		if err := store.Insert(task); err == nil {
+			t.Fatalf("expected error inserting duplicate ID")
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		store := open(t, newPath(t))
+
+		_, err := store.Get(42)
+		assertNotFound(t, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo})
+
+		if err := store.Update(domain.Task{ID: 1, Description: "A2", Status: domain.StatusDone}); err != nil {
+			t.Fatalf("update failed: %v", err)
+		}
+
+		got, err := store.Get(1)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		if got.Description != "A2" || got.Status != domain.StatusDone {
+			t.Errorf("update not persisted: %+v", got)
+		}
+	})
+
+	t.Run("UpdateBumpsRevision", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: 1})
+
+		for want := 2; want <= 3; want++ {
+			task, err := store.Get(1)
+			if err != nil {
+				t.Fatalf("get failed: %v", err)
+			}
+			if err := store.Update(task); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+
+			got, err := store.Get(1)
+			if err != nil {
+				t.Fatalf("get failed: %v", err)
+			}
+			if got.Revision != want {
+				t.Errorf("expected revision %d, got %d", want, got.Revision)
+			}
+		}
+	})
+
+	t.Run("UpdateStaleRevision", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: 1})
+
+		mine, _ := store.Get(1)
+		theirs, _ := store.Get(1)
+
+		theirs.Description = "theirs"
+		if err := store.Update(theirs); err != nil {
+			t.Fatalf("update failed: %v", err)
+		}
+
+		mine.Description = "mine"
+		err := store.Update(mine)
+
+		var ce *domain.ConflictError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected ConflictError, got %T: %v", err, err)
+		}
+
+		got, _ := store.Get(1)
+		if got.Description != "theirs" {
+			t.Errorf("stale update must not be applied, got %q", got.Description)
+		}
+	})
+
+	t.Run("GetReturnsACopy", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo, BlockedBy: []int{2, 3}})
+
+		err := store.Atomic(func(tx ports.TaskStore) error {
+			task, err := tx.Get(1)
+			if err != nil {
+				return err
+			}
+			task.BlockedBy[0] = 4 // in place, without an Update
+
+			again, err := tx.Get(1)
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(again.BlockedBy, []int{2, 3}) {
+				t.Errorf("expected the stored task to be unchanged, got %v", again.BlockedBy)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("atomic failed: %v", err)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		store := open(t, newPath(t))
+
+		err := store.Update(domain.Task{ID: 42, Description: "ghost", Status: domain.StatusTodo})
+		assertNotFound(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo})
+		mustInsert(t, store, domain.Task{ID: 2, Description: "B", Status: domain.StatusTodo})
+
+		if err := store.Delete(1); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+
+		got, err := store.List()
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != 2 {
+			t.Errorf("expected only task 2 to remain, got %+v", got)
+		}
+
+		assertNotFound(t, store.Delete(1))
+	})
+
+	t.Run("NextIDNeverReusesDeletedIDs", func(t *testing.T) {
+		path := newPath(t)
+		store := open(t, path)
+
+		var last int
+		for i := 0; i < 3; i++ {
+			id, err := store.NextID()
+			if err != nil {
+				t.Fatalf("next id failed: %v", err)
+			}
+			mustInsert(t, store, domain.Task{ID: id, Description: "task", Status: domain.StatusTodo})
+			last = id
+		}
+
+		if err := store.Delete(last); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+
+		// A fresh handle must continue the persisted sequence
+		id, err := open(t, path).NextID()
+		if err != nil {
+			t.Fatalf("next id failed: %v", err)
+		}
+		if id <= last {
+			t.Errorf("expected an ID above deleted %d, got %d", last, id)
+		}
+	})
+
+	t.Run("NextIDSkipsExistingIDs", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 10, Description: "imported", Status: domain.StatusTodo})
+
+		id, err := store.NextID()
+		if err != nil {
+			t.Fatalf("next id failed: %v", err)
+		}
+		if id != 11 {
+			t.Errorf("expected 11, got %d", id)
+		}
+	})
+
+	t.Run("ListByStatus", func(t *testing.T) {
+		store := open(t, newPath(t))
+		mustInsert(t, store, domain.Task{ID: 1, Description: "A", Status: domain.StatusDone})
+		mustInsert(t, store, domain.Task{ID: 2, Description: "B", Status: domain.StatusTodo})
+		mustInsert(t, store, domain.Task{ID: 3, Description: "C", Status: domain.StatusDone})
+
+		got, err := store.ListByStatus(domain.StatusDone)
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("expected 2 done tasks, got %+v", got)
+		}
+		for _, task := range got {
+			if task.Status != domain.StatusDone {
+				t.Errorf("unexpected status in %+v", task)
+			}
+		}
+	})
+
+	t.Run("Projects", func(t *testing.T) {
+		path := newPath(t)
+		store := open(t, path)
+		for _, p := range []domain.Project{
+			{Name: "web", CreatedAt: "2025-01-02T10:00:00Z"},
+			{Name: "api", CreatedAt: "2025-01-01T10:00:00Z"},
+		} {
+			if err := store.SaveProject(p); err != nil {
+				t.Fatalf("save project failed: %v", err)
+			}
+		}
+		if err := store.DeleteProject("web"); err != nil {
+			t.Fatalf("delete project failed: %v", err)
+		}
+		assertNotFound(t, store.DeleteProject("web"))
+
+		got, err := open(t, path).Projects()
+		if err != nil {
+			t.Fatalf("list projects failed: %v", err)
+		}
+		want := []domain.Project{{Name: "api", CreatedAt: "2025-01-01T10:00:00Z"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+}
+
+func mustInsert(t *testing.T, store ports.TaskStore, task domain.Task) {
+	t.Helper()
+	if err := store.Insert(task); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+
+	var nf *domain.NotFoundError
+	if !errors.As(err, &nf) {
+		t.Fatalf("expected NotFoundError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,138 @@
+package sqliterepo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"taskcli/internal/domain"
+)
+
+// column maps a tasks table column onto a domain.Task field.
+// field returns a pointer that is used both as scan destination and as bound value.
+type column struct {
+	name  string
+	field func(t *domain.Task) any
+}
+
+// columns is the single source of truth for reading and writing task rows.
+// Add new fields here together with a migration in schema.go.
+var columns = []column{
+	{"id", func(t *domain.Task) any { return &t.ID }},
+	{"description", func(t *domain.Task) any { return &t.Description }},
+	{"status", func(t *domain.Task) any { return &t.Status }},
+	{"created_at", func(t *domain.Task) any { return &t.CreatedAt }},
+	{"updated_at", func(t *domain.Task) any { return &t.UpdatedAt }},
+	{"revision", func(t *domain.Task) any { return &t.Revision }},
+	{"priority", func(t *domain.Task) any { return &t.Priority }},
+	{"due", func(t *domain.Task) any { return &t.Due }},
+	{"tags", func(t *domain.Task) any { return jsonField{&t.Tags} }},
+	{"parent_id", func(t *domain.Task) any { return &t.ParentID }},
+	{"blocked_by", func(t *domain.Task) any { return jsonField{&t.BlockedBy} }},
+	{"recurrence", func(t *domain.Task) any { return &t.Recurrence }},
+	{"series_id", func(t *domain.Task) any { return &t.SeriesID }},
+	{"time_entries", func(t *domain.Task) any { return jsonField{&t.TimeEntries} }},
+	{"history", func(t *domain.Task) any { return jsonField{&t.History} }},
+	{"deleted_at", func(t *domain.Task) any { return &t.DeletedAt }},
+	{"comments", func(t *domain.Task) any { return jsonField{&t.Comments} }},
+	{"project", func(t *domain.Task) any { return &t.Project }},
+	{"fields", func(t *domain.Task) any { return jsonField{&t.Fields} }},
+	{"checklist", func(t *domain.Task) any { return jsonField{&t.Checklist} }},
+	{"estimate", func(t *domain.Task) any { return &t.Estimate }},
+	{"assignees", func(t *domain.Task) any { return jsonField{&t.Assignees} }},
+	{"anchor_day", func(t *domain.Task) any { return &t.AnchorDay }},
+}
+
+// jsonField stores a collection field as JSON text.
+// It wraps a pointer so it can act as both scan destination and bound value.
+type jsonField struct{ ptr any }
+
+func (j jsonField) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.ptr)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (j jsonField) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("cannot scan %T into JSON column", src)
+	}
+
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, j.ptr)
+}
+
+var (
+	taskColumns = columnNames()
+	insertSQL   = buildInsert()
+	updateSQL   = buildUpdate()
+)
+
+func columnNames() string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// buildInsert renders an upsert that overwrites every column of an existing row.
+func buildInsert() string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+
+	var set []string
+	for _, c := range columns[1:] {
+		set = append(set, c.name+" = excluded."+c.name)
+	}
+
+	return "INSERT INTO tasks (" + taskColumns + ") VALUES (" + placeholders + ")" +
+		" ON CONFLICT(id) DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+// buildUpdate renders an optimistic update: it only matches the expected revision and bumps it.
+// Its arguments are updateValues followed by the id and the expected revision.
+func buildUpdate() string {
+	var set []string
+	for _, c := range columns {
+		if c.name == "id" || c.name == "revision" {
+			continue
+		}
+		set = append(set, c.name+" = ?")
+	}
+	set = append(set, "revision = revision + 1")
+
+	return "UPDATE tasks SET " + strings.Join(set, ", ") + " WHERE id = ? AND revision = ?"
+}
+
+// values returns the bound values of t in column order.
+func values(t *domain.Task) []any {
+	vals := make([]any, len(columns))
+	for i, c := range columns {
+		vals[i] = c.field(t)
+	}
+	return vals
+}
+
+// updateValues returns the arguments for updateSQL.
+func updateValues(t *domain.Task) []any {
+	var vals []any
+	for _, c := range columns {
+		if c.name == "id" || c.name == "revision" {
+			continue
+		}
+		vals = append(vals, c.field(t))
+	}
+	return append(vals, t.ID, t.Revision)
+}
@@ -0,0 +1,335 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+
+	_ "modernc.org/sqlite" // pure Go driver, no cgo required
+)
+
+var (
+	_ ports.TaskRepository = (*Repo)(nil)
+	_ ports.TaskStore      = (*Repo)(nil)
+)
+
+// Repo implements TaskRepository and TaskStore against an embedded SQLite database.
+// Unlike fsrepo, the TaskStore methods only write the row of the task they are given instead of
+// rewriting the whole store; Save, the bulk TaskRepository path, upserts every task it is given.
+type Repo struct {
+	db *sql.DB // nil inside Atomic
+	q  execer  // db, or the transaction of the current unit of work
+}
+
+// DefaultLockTimeout is how long a write waits for a concurrent invocation to release the database.
+const DefaultLockTimeout = 5 * time.Second
+
+type options struct{ lockTimeout time.Duration }
+
+// Option customizes a Repo.
+type Option func(*options)
+
+// WithLockTimeout sets how long writes wait for the database lock (SQLite busy_timeout).
+func WithLockTimeout(d time.Duration) Option {
+	return func(o *options) { o.lockTimeout = d }
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// New opens (or creates) the SQLite database at filename and applies pending migrations.
+// filename is interpreted relative to the current working directory if not absolute
+func New(filename string, opts ...Option) (*Repo, error) {
+	if filename == "" {
+		filename = "tasks.db"
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(abs); err == nil && info.IsDir() {
+		return nil, errors.New("tasks path is directory, expected a file")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+		return nil, err
+	}
+
+	o := options{lockTimeout: DefaultLockTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// busy_timeout lets concurrent invocations wait for each other instead of failing immediately;
+	// _txlock=immediate takes the write lock when a unit of work begins, so read-modify-write cycles cannot interleave
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_txlock=immediate", abs, o.lockTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Repo{db: db, q: db}, nil
+}
+
+// Close releases the underlying database handle.
+func (r *Repo) Close() error {
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Close()
+}
+
+// Atomic runs fn inside a database transaction that is committed only if fn succeeds.
+func (r *Repo) Atomic(fn func(tx ports.TaskStore) error) error {
+	if r.db == nil {
+		return fn(r) // already inside a transaction
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(&Repo{q: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repo) Load() ([]domain.Task, error) {
+	return r.List()
+}
+
+// Save makes the stored collection match tasks inside a single transaction:
+// given tasks are upserted and rows whose ID is absent are deleted.
+func (r *Repo) Save(tasks []domain.Task) error {
+	return r.Atomic(func(tx ports.TaskStore) error {
+		q := tx.(*Repo).q
+
+		keep := make(map[int]bool, len(tasks))
+		for _, t := range tasks {
+			keep[t.ID] = true
+			if err := upsert(q, t); err != nil {
+				return err
+			}
+		}
+
+		stale, err := staleIDs(q, keep)
+		if err != nil {
+			return err
+		}
+		for _, id := range stale {
+			if _, err := q.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Repo) Get(id int) (domain.Task, error) {
+	t, err := scanTask(r.q.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Task{}, &domain.NotFoundError{Msg: "task not found"}
+	}
+
+	return t, err
+}
+
+func (r *Repo) List() ([]domain.Task, error) {
+	return queryTasks(r.q, `SELECT `+taskColumns+` FROM tasks ORDER BY id`)
+}
+
+func (r *Repo) ListByStatus(status domain.TaskStatus) ([]domain.Task, error) {
+	return queryTasks(r.q, `SELECT `+taskColumns+` FROM tasks WHERE status = ? ORDER BY id`, status)
+}
+
+func (r *Repo) Insert(t domain.Task) error {
+	var exists bool
+	if err := r.q.QueryRow(`SELECT EXISTS(SELECT 1 FROM tasks WHERE id = ?)`, t.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("task %d already exists", t.ID)
+	}
+
+	return upsert(r.q, t)
+}
+
+func (r *Repo) Update(t domain.Task) error {
+	res, err := r.q.Exec(updateSQL, updateValues(&t)...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+
+	// Nothing matched: tell a missing task apart from a stale revision
+	var stored int
+	err = r.q.QueryRow(`SELECT revision FROM tasks WHERE id = ?`, t.ID).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &domain.NotFoundError{Msg: "task not found"}
+	}
+	if err != nil {
+		return err
+	}
+
+	return domain.NewConflictError(t.ID, t.Revision, stored)
+}
+
+func (r *Repo) Delete(id int) error {
+	res, err := r.q.Exec(`DELETE FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(res)
+}
+
+// NextID bumps the persisted task sequence; it never hands out an ID that was used before,
+// even if rows were inserted with explicit IDs through Save.
+func (r *Repo) NextID() (int, error) {
+	var id int
+	err := r.q.QueryRow(`
+		UPDATE sequences
+		SET value = MAX(value, (SELECT COALESCE(MAX(id), 0) FROM tasks)) + 1
+		WHERE name = 'tasks'
+		RETURNING value`,
+	).Scan(&id)
+
+	return id, err
+}
+
+func (r *Repo) Projects() ([]domain.Project, error) {
+	rows, err := r.q.Query(`SELECT name, created_at FROM projects ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []domain.Project{}
+	for rows.Next() {
+		var p domain.Project
+		if err := rows.Scan(&p.Name, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
+func (r *Repo) SaveProject(p domain.Project) error {
+	_, err := r.q.Exec(`INSERT OR REPLACE INTO projects (name, created_at) VALUES (?, ?)`, p.Name, p.CreatedAt)
+	return err
+}
+
+func (r *Repo) DeleteProject(name string) error {
+	res, err := r.q.Exec(`DELETE FROM projects WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &domain.NotFoundError{Msg: "project not found"}
+	}
+
+	return nil
+}
+
+func upsert(db execer, t domain.Task) error {
+	_, err := db.Exec(insertSQL, values(&t)...)
+	return err
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row scanner) (domain.Task, error) {
+	var t domain.Task
+	err := row.Scan(values(&t)...)
+	return t, err
+}
+
+func queryTasks(db execer, query string, args ...any) ([]domain.Task, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []domain.Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// requireRow turns "no rows affected" into a NotFoundError.
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &domain.NotFoundError{Msg: "task not found"}
+	}
+
+	return nil
+}
+
+// staleIDs returns stored IDs that are not present in keep.
+func staleIDs(q execer, keep map[int]bool) ([]int, error) {
+	rows, err := q.Query(`SELECT id FROM tasks`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		if !keep[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, rows.Err()
+}
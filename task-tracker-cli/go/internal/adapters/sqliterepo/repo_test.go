@@ -0,0 +1,70 @@
+package sqliterepo
+
+import (
+	"errors"
+	"path/filepath"
+	"taskcli/internal/adapters/repotest"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"testing"
+)
+
+func open(t *testing.T, path string) ports.TaskRepository {
+	t.Helper()
+
+	repo, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create repo: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	return repo
+}
+
+func TestRepoContract(t *testing.T) {
+	repotest.Run(t, open)
+}
+
+func TestStoreContract(t *testing.T) {
+	repotest.RunStore(t, func(t *testing.T, path string) ports.TaskStore {
+		return open(t, path).(*Repo)
+	})
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	open(t, path)
+	repo := open(t, path)
+
+	var version int
+	if err := repo.(*Repo).db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != len(migrations) {
+		t.Errorf("expected schema version %d, got %d", len(migrations), version)
+	}
+}
+
+func TestAtomic_RollsBackOnError(t *testing.T) {
+	repo := open(t, filepath.Join(t.TempDir(), "tasks.db")).(*Repo)
+
+	boom := errors.New("boom")
+	err := repo.Atomic(func(tx ports.TaskStore) error {
+		if err := tx.Insert(domain.Task{ID: 1, Description: "A", Status: domain.StatusTodo}); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+
+	tasks, err := repo.List()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("expected rollback, got %+v", tasks)
+	}
+}
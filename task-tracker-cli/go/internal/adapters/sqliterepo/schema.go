@@ -0,0 +1,83 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order and tracked through PRAGMA user_version.
+// Never edit an entry once released; append a new one instead.
+var migrations = []string{
+	`CREATE TABLE tasks (
+		id          INTEGER PRIMARY KEY,
+		description TEXT    NOT NULL,
+		status      TEXT    NOT NULL,
+		created_at  TEXT    NOT NULL DEFAULT '',
+		updated_at  TEXT    NOT NULL DEFAULT ''
+	);
+	CREATE INDEX idx_tasks_status ON tasks(status);`,
+	`ALTER TABLE tasks ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE sequences (
+		name  TEXT    PRIMARY KEY,
+		value INTEGER NOT NULL
+	);
+	INSERT INTO sequences (name, value) SELECT 'tasks', COALESCE(MAX(id), 0) FROM tasks;`,
+	`ALTER TABLE tasks ADD COLUMN priority TEXT NOT NULL DEFAULT 'medium';`,
+	`ALTER TABLE tasks ADD COLUMN due TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN parent_id INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX idx_tasks_parent_id ON tasks(parent_id);`,
+	`ALTER TABLE tasks ADD COLUMN blocked_by TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tasks ADD COLUMN series_id INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE tasks ADD COLUMN time_entries TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN history TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN deleted_at TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN comments TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN project TEXT NOT NULL DEFAULT '';
+	CREATE TABLE projects (
+		name       TEXT PRIMARY KEY,
+		created_at TEXT NOT NULL DEFAULT ''
+	);`,
+	`ALTER TABLE tasks ADD COLUMN fields TEXT NOT NULL DEFAULT '{}';`,
+	`ALTER TABLE tasks ADD COLUMN checklist TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN estimate TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN assignees TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE tasks ADD COLUMN anchor_day INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// migrate brings the database schema up to date.
+// The version is read inside the (immediate) transaction so concurrent first runs
+// cannot both try to apply the same migration.
+func migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer func() { _ = tx.Rollback() }()
+
+	var version int
+	if err := tx.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			return fmt.Errorf("apply migration %d: %w", i+1, err)
+		}
+	}
+	// PRAGMA does not accept bound parameters
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations))); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,188 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+)
+
+// WithArchive sets the store that Archive moves old closed tasks to, keeping the active store small.
+// Archived tasks keep the status they were archived in, even one the workflow no longer declares.
+func WithArchive(archive ports.TaskStore) Option {
+	return func(s *TaskService) { s.archive = archive }
+}
+
+// ArchiveQuery selects the tasks moved by Archive.
+type ArchiveQuery struct {
+	Done      bool          // tasks in a done status
+	Cancelled bool          // tasks in a cancelled status
+	OlderThan time.Duration // closed at least this long ago
+}
+
+func (q ArchiveQuery) matches(w domain.Workflow, t *domain.Task, cutoff time.Time) (bool, error) {
+	category, _ := w.Category(t.Status)
+	switch {
+	case q.Done && category == domain.CategoryDone:
+	case q.Cancelled && category == domain.CategoryCancelled:
+	default:
+		return false, nil
+	}
+
+	closed, err := t.ClosedAt(w)
+	if err != nil {
+		return false, err
+	}
+	return !closed.After(cutoff), nil
+}
+
+// Archive moves the tasks matching q from the active store to the archive and returns them.
+// A task is only archived together with all of its subtasks, so no active subtask loses its parent.
+func (s *TaskService) Archive(q ArchiveQuery) ([]domain.Task, error) {
+	if s.archive == nil {
+		return nil, errNoArchive
+	}
+	if !q.Done && !q.Cancelled {
+		return nil, &domain.ValidationError{Msg: "nothing to archive: select done and/or cancelled tasks"}
+	}
+
+	// moving tasks between stores cannot be undone, so it bypasses the journal and leaves a barrier in it
+	cutoff := s.now().Add(-q.OlderThan)
+	var archived []domain.Task
+	err := s.journaled(func() (*ports.JournalEntry, error) {
+		err := liveStore{s.raw}.Atomic(func(tx ports.TaskStore) error {
+			tasks, err := tx.List()
+			if err != nil {
+				return err
+			}
+
+			selected := map[int]bool{}
+			for _, t := range tasks {
+				ok, err := q.matches(s.workflow, &t, cutoff)
+				if err != nil {
+					return err
+				}
+				selected[t.ID] = ok
+			}
+			// dropping a task can leave its parent with a subtask that stays, so repeat until nothing changes
+			for changed := true; changed; {
+				changed = false
+				for _, t := range tasks {
+					if t.ParentID != 0 && selected[t.ParentID] && !selected[t.ID] {
+						selected[t.ParentID] = false
+						changed = true
+					}
+				}
+			}
+
+			for _, t := range tasks {
+				if selected[t.ID] {
+					archived = append(archived, t)
+				}
+			}
+			if len(archived) == 0 {
+				return nil
+			}
+
+			// the archive is written first: an interruption leaves a task in both stores rather than in neither
+			err = s.archive.Atomic(func(atx ports.TaskStore) error {
+				for _, t := range archived {
+					if err := atx.Delete(t.ID); err != nil && !isNotFound(err) {
+						return err
+					}
+					if err := atx.Insert(t); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, t := range archived {
+				if err := tx.Delete(t.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil || len(archived) == 0 {
+			return nil, err
+		}
+		return s.barrier(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return archived, nil
+}
+
+// ListArchived lists the archived tasks matching q.
+func (s *TaskService) ListArchived(q ListQuery) ([]domain.Task, error) {
+	if s.archive == nil {
+		return nil, errNoArchive
+	}
+
+	return s.listFrom(s.archive, q)
+}
+
+// Unarchive moves a task back from the archive to the active store. A subtask can only come back once its parent has,
+// and a task whose project was deleted meanwhile comes back in the default project.
+func (s *TaskService) Unarchive(id int) error {
+	if s.archive == nil {
+		return errNoArchive
+	}
+
+	// like Archive, the store the task leaves is locked first and written last,
+	// so an interruption leaves the task in both stores rather than in neither;
+	// the two lock the stores in opposite order, but both hold the journal before either store
+	return s.journaled(func() (*ports.JournalEntry, error) {
+		err := s.archive.Atomic(func(atx ports.TaskStore) error {
+			task, err := atx.Get(id)
+			if isNotFound(err) {
+				return &domain.NotFoundError{Msg: fmt.Sprintf("task %d is not archived", id)}
+			}
+			if err != nil {
+				return err
+			}
+
+			err = liveStore{s.raw}.Atomic(func(tx ports.TaskStore) error {
+				if task.ParentID != 0 {
+					if _, err := tx.Get(task.ParentID); err != nil {
+						if _, archErr := atx.Get(task.ParentID); archErr == nil {
+							return &domain.ValidationError{Msg: fmt.Sprintf("parent task %d is archived; unarchive it first", task.ParentID)}
+						}
+						return parentError(task.ParentID, err)
+					}
+				}
+
+				before := task.Clone()
+				if err := rehome(tx, &task); err != nil {
+					return err
+				}
+				task.RecordChanges(before, s.now(), s.actor)
+				return tx.Insert(task)
+			})
+			if err != nil {
+				return err
+			}
+
+			return atx.Delete(id)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.barrier(), nil
+	})
+}
+
+var errNoArchive = errors.New("no archive store configured")
+
+// isNotFound reports whether err means the task does not exist.
+func isNotFound(err error) bool {
+	var notFound *domain.NotFoundError
+	return errors.As(err, &notFound)
+}
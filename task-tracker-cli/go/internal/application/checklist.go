@@ -0,0 +1,59 @@
+package application
+
+import (
+	"errors"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+)
+
+// WithAutoDone makes ToggleCheckItem complete a task once every item of its checklist is checked.
+func WithAutoDone(on bool) Option {
+	return func(s *TaskService) { s.autoDone = on }
+}
+
+// AddCheckItem appends an item to the checklist of task id.
+func (s *TaskService) AddCheckItem(id int, text string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.AddCheckItem(text)
+	})
+}
+
+// ToggleCheckItem checks or unchecks the n-th checklist item of task id and returns it.
+// With WithAutoDone, checking the last open item also completes the task in the same unit of work,
+// unless the workflow or open subtasks prevent it; completed tells whether it did, and next is the follow-up
+// of a recurring task it completed.
+func (s *TaskService) ToggleCheckItem(id, n int) (item domain.CheckItem, completed bool, next *domain.Task, err error) {
+	err = s.withTaskTx(id, func(tx ports.TaskStore, t *domain.Task) error {
+		if item, err = t.ToggleCheckItem(n); err != nil {
+			return err
+		}
+		if !s.autoDone || !item.Done || !t.ChecklistComplete() || s.workflow.IsClosed(t.Status) {
+			return nil
+		}
+
+		tr, ok := s.workflow.DoneTransition(t.Status)
+		if !ok {
+			return nil
+		}
+		attempt := t.Clone()
+		occurrence, err := s.applyTransition(tx, &attempt, tr, false)
+		if err != nil {
+			var validationErr *domain.ValidationError
+			if errors.As(err, &validationErr) {
+				return nil // the item stays checked; the task is completed by hand later
+			}
+			return err
+		}
+
+		*t, completed, next = attempt, true, occurrence
+		return nil
+	})
+	return item, completed, next, err
+}
+
+// RemoveCheckItem drops the n-th checklist item of task id.
+func (s *TaskService) RemoveCheckItem(id, n int) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.RemoveCheckItem(n)
+	})
+}
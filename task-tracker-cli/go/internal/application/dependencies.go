@@ -0,0 +1,148 @@
+package application
+
+import (
+	"sort"
+	"taskcli/internal/domain"
+)
+
+// Ready returns the tasks that can be started now: in a declared status that is not closed and with every blocker closed.
+// Tasks that unblock the most remaining work come first, then higher priority, then lower ID.
+func (s *TaskService) Ready() ([]domain.Task, error) {
+	tasks, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	waiting := openDependents(s.workflow, tasks)
+
+	var ready []domain.Task
+	for _, t := range tasks {
+		if s.workflow.Declares(t.Status) && !s.workflow.IsClosed(t.Status) && len(domain.OpenBlockers(s.workflow, tasks, t)) == 0 {
+			ready = append(ready, t)
+		}
+	}
+
+	sort.SliceStable(ready, func(i, j int) bool {
+		a, b := ready[i], ready[j]
+		if wa, wb := waiting[a.ID], waiting[b.ID]; wa != wb {
+			return wa > wb
+		}
+		if ra, rb := a.Priority.Rank(), b.Priority.Rank(); ra != rb {
+			return ra > rb
+		}
+		return a.ID < b.ID
+	})
+
+	return ready, nil
+}
+
+// DepNode is one line of a dependency graph in display order.
+type DepNode struct {
+	Task   domain.Task
+	Depth  int
+	Repeat bool // listed above along another path; its own dependencies are not listed again
+}
+
+// DepGraph is the neighbourhood of one task: what it waits for and what waits for it, transitively.
+type DepGraph struct {
+	Task      domain.Task
+	BlockedBy []DepNode
+	Blocks    []DepNode
+}
+
+// Deps returns the dependency graph around task id.
+// A task reachable along several paths is listed in full under the first one and as a Repeat under the others.
+func (s *TaskService) Deps(id int) (DepGraph, error) {
+	tasks, err := s.store.List()
+	if err != nil {
+		return DepGraph{}, err
+	}
+
+	byID := make(map[int]domain.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	task, ok := byID[id]
+	if !ok {
+		return DepGraph{}, &domain.NotFoundError{Msg: "task not found"}
+	}
+
+	upstream := func(t domain.Task) []domain.Task {
+		var res []domain.Task
+		for _, b := range t.BlockedBy {
+			if blocker, ok := byID[b]; ok {
+				res = append(res, blocker)
+			}
+		}
+		return res
+	}
+	downstream := func(t domain.Task) []domain.Task {
+		return domain.Dependents(tasks, t.ID)
+	}
+
+	return DepGraph{
+		Task:      task,
+		BlockedBy: walkDeps(task, upstream),
+		Blocks:    walkDeps(task, downstream),
+	}, nil
+}
+
+// walkDeps flattens the graph reachable from root through next, depth first.
+// Every task is expanded once, so shared dependencies cannot make the result grow exponentially.
+func walkDeps(root domain.Task, next func(domain.Task) []domain.Task) []DepNode {
+	var nodes []DepNode
+	listed := map[int]bool{root.ID: true}
+
+	var visit func(t domain.Task, depth int)
+	visit = func(t domain.Task, depth int) {
+		for _, n := range next(t) {
+			if n.ID == root.ID {
+				continue // corrupted cycle
+			}
+			if listed[n.ID] {
+				nodes = append(nodes, DepNode{Task: n, Depth: depth, Repeat: true})
+				continue
+			}
+			nodes = append(nodes, DepNode{Task: n, Depth: depth})
+
+			listed[n.ID] = true
+			visit(n, depth+1)
+		}
+	}
+	visit(root, 0)
+
+	return nodes
+}
+
+// openDependents counts, for every task, the tasks open in workflow w that transitively wait for it.
+func openDependents(w domain.Workflow, tasks []domain.Task) map[int]int {
+	dependents := map[int][]int{}
+	status := make(map[int]domain.TaskStatus, len(tasks))
+	for _, t := range tasks {
+		status[t.ID] = t.Status
+		for _, b := range t.BlockedBy {
+			dependents[b] = append(dependents[b], t.ID)
+		}
+	}
+
+	res := make(map[int]int, len(tasks))
+	for _, t := range tasks {
+		seen := map[int]bool{t.ID: true}
+		stack := append([]int(nil), dependents[t.ID]...)
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if !w.IsClosed(status[id]) {
+				res[t.ID]++
+			}
+			stack = append(stack, dependents[id]...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,106 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"taskcli/internal/domain"
+)
+
+// FieldFilter selects tasks by custom field; every condition must hold.
+type FieldFilter []fieldCond
+
+type fieldCond struct {
+	def   domain.FieldDef
+	op    string // "=", "!=", "<", "<=", ">", ">=", "set" or "unset"
+	value string // canonical, see domain.FieldDef.Parse
+}
+
+// operators are listed longest first so that "<=" is not read as "<" where both match
+var fieldOperators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// ParseFieldFilter builds a filter on fields from expressions such as "customer=acme", "estimate>=3",
+// "ticket" (the field is set) or "!ticket" (it is not).
+func ParseFieldFilter(fields domain.FieldSet, exprs []string) (FieldFilter, error) {
+	var f FieldFilter
+	for _, expr := range exprs {
+		cond, err := parseFieldCond(fields, strings.TrimSpace(expr))
+		if err != nil {
+			return nil, err
+		}
+		f = append(f, cond)
+	}
+
+	return f, nil
+}
+
+func parseFieldCond(fields domain.FieldSet, expr string) (fieldCond, error) {
+	name, op, raw := expr, "set", ""
+	if strings.HasPrefix(expr, "!") && !strings.ContainsAny(expr, "=<>") {
+		name, op = expr[1:], "unset"
+	}
+	// the first operator in the expression splits it, so that the value may contain operators itself
+	at := -1
+	for _, candidate := range fieldOperators {
+		if i := strings.Index(expr, candidate); i > 0 && (at < 0 || i < at) {
+			at, op = i, candidate
+		}
+	}
+	if at > 0 {
+		name, raw = strings.TrimSpace(expr[:at]), expr[at+len(op):]
+	}
+
+	def, ok := fields.Lookup(name)
+	if !ok {
+		return fieldCond{}, &domain.ValidationError{Msg: fmt.Sprintf("invalid field filter %q: no custom field %q", expr, name)}
+	}
+	cond := fieldCond{def: def, op: op}
+	if op == "set" || op == "unset" {
+		return cond, nil
+	}
+
+	var err error
+	if cond.value, err = def.Parse(raw); err != nil {
+		return fieldCond{}, err
+	}
+	return cond, nil
+}
+
+// Matches reports whether t satisfies every condition; a task without the field only matches "!=" and "unset".
+func (f FieldFilter) Matches(t *domain.Task) bool {
+	for _, c := range f {
+		if !c.matches(t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c fieldCond) matches(t *domain.Task) bool {
+	v, set := t.Fields[c.def.Name]
+	switch c.op {
+	case "set":
+		return set
+	case "unset":
+		return !set
+	case "!=":
+		return !set || v != c.value
+	}
+	if !set {
+		return false
+	}
+
+	cmp := c.def.Compare(v, c.value)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
@@ -0,0 +1,472 @@
+package application
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+)
+
+// MaxUndo is how many commands the journal keeps for Undo.
+const MaxUndo = 50
+
+// WithJournal records the tasks changed by every unit of work under command, so that Undo and Redo can revert and replay them.
+func WithJournal(j ports.Journal, command string) Option {
+	return func(s *TaskService) {
+		s.journal = j
+		s.command = command
+	}
+}
+
+// journalStore pushes the tasks written by each top-level unit of work to the journal once it committed.
+// The journal is held from before the store is locked until the entry is written, so that concurrent commands
+// are journaled in the order they commit; see TaskService.journaled. Writes outside Atomic are units of work of their own.
+type journalStore struct {
+	ports.TaskStore
+	svc *TaskService
+}
+
+func (s journalStore) Insert(task domain.Task) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.Insert(task) })
+}
+
+func (s journalStore) Update(task domain.Task) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.Update(task) })
+}
+
+func (s journalStore) Delete(id int) error {
+	return s.Atomic(func(tx ports.TaskStore) error { return tx.Delete(id) })
+}
+
+func (s journalStore) Atomic(fn func(tx ports.TaskStore) error) error {
+	return s.svc.journaled(func() (*ports.JournalEntry, error) {
+		var changes []ports.TaskChange
+		err := s.TaskStore.Atomic(func(tx ports.TaskStore) error {
+			rec := &recorder{TaskStore: tx, before: map[int]*domain.Task{}}
+			if err := fn(rec); err != nil {
+				return err
+			}
+
+			var err error
+			changes, err = rec.changes()
+			return err
+		})
+		if err != nil || len(changes) == 0 {
+			return nil, err
+		}
+		return &ports.JournalEntry{Command: s.svc.command, At: s.svc.now().UTC().Format(time.RFC3339), Changes: changes}, nil
+	})
+}
+
+// journaled runs unit, which commits a unit of work on the store, while holding the journal,
+// and pushes the entry it returns (if any) only once unit succeeded, so that a unit of work that fails,
+// even at its commit, is never journaled. The journal is always taken before the store is locked,
+// so that concurrent invocations cannot deadlock.
+func (s *TaskService) journaled(unit func() (*ports.JournalEntry, error)) error {
+	if s.journal == nil {
+		_, err := unit()
+		return err
+	}
+
+	var ran, committed bool
+	err := s.journal.Update(func(stacks *ports.JournalStacks) error {
+		ran = true
+		entry, err := unit()
+		if err != nil {
+			return err
+		}
+		committed = true
+		if entry == nil {
+			return errNothingJournaled
+		}
+
+		stacks.Undo = append(stacks.Undo, *entry)
+		if n := len(stacks.Undo); n > MaxUndo {
+			stacks.Undo = stacks.Undo[n-MaxUndo:]
+		}
+		stacks.Redo = nil // a new change starts a new branch
+		return nil
+	})
+	switch {
+	case err == nil || errors.Is(err, errNothingJournaled):
+		return nil
+	case !ran:
+		return fmt.Errorf("changes not saved, the journal cannot be written: %w", err)
+	case committed:
+		return fmt.Errorf("changes saved but not journaled, so they cannot be undone: %w", err)
+	}
+	return err
+}
+
+// errNothingJournaled leaves the journal as it was after a unit of work that changed no task
+var errNothingJournaled = errors.New("nothing to journal")
+
+// barrier is the journal entry of a command whose changes the journal does not hold,
+// such as moving tasks between the active store and the archive, so that Undo stops there
+// instead of reverting older commands over tasks that have moved.
+func (s *TaskService) barrier() *ports.JournalEntry {
+	return &ports.JournalEntry{Command: s.command, At: s.now().UTC().Format(time.RFC3339), Barrier: true}
+}
+
+// recorder remembers the state of every task before the first write to it within a unit of work.
+type recorder struct {
+	ports.TaskStore
+	order  []int
+	before map[int]*domain.Task // nil for a task that did not exist
+}
+
+func (r *recorder) Insert(task domain.Task) error {
+	if err := r.touch(task.ID); err != nil {
+		return err
+	}
+	return r.TaskStore.Insert(task)
+}
+
+func (r *recorder) Update(task domain.Task) error {
+	if err := r.touch(task.ID); err != nil {
+		return err
+	}
+	return r.TaskStore.Update(task)
+}
+
+func (r *recorder) Delete(id int) error {
+	if err := r.touch(id); err != nil {
+		return err
+	}
+	return r.TaskStore.Delete(id)
+}
+
+// Atomic keeps nested units of work within the current one, and recorded.
+func (r *recorder) Atomic(fn func(tx ports.TaskStore) error) error {
+	return fn(r)
+}
+
+func (r *recorder) touch(id int) error {
+	if _, seen := r.before[id]; seen {
+		return nil
+	}
+
+	t, err := lookup(r.TaskStore, id)
+	if err != nil {
+		return err
+	}
+	r.before[id] = t
+	r.order = append(r.order, id)
+	return nil
+}
+
+// changes pairs the recorded states with the current ones, in the order the tasks were first written.
+func (r *recorder) changes() ([]ports.TaskChange, error) {
+	var res []ports.TaskChange
+	for _, id := range r.order {
+		after, err := lookup(r.TaskStore, id)
+		if err != nil {
+			return nil, err
+		}
+		before := r.before[id]
+		if before == nil && after == nil {
+			continue
+		}
+
+		c := ports.TaskChange{ID: id}
+		if c.Before, c.After, err = diffStates(before, after); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// unjournaled fields are never restored: the history is kept and records the reverted fields,
+// the update time is set by the revert, and the revision by the store
+var unjournaled = []string{"history", "updatedAt", "revision"}
+
+// diffStates reduces the two states of a task to the fields that differ between them.
+// A state without a counterpart keeps every field, so that the task can be inserted again as it was.
+func diffStates(before, after *domain.Task) (*ports.TaskState, *ports.TaskState, error) {
+	if before == nil || after == nil {
+		b, err := fullState(before)
+		if err != nil {
+			return nil, nil, err
+		}
+		a, err := fullState(after)
+		return b, a, err
+	}
+
+	bf, err := taskFields(*before)
+	if err != nil {
+		return nil, nil, err
+	}
+	af, err := taskFields(*after)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range unjournaled {
+		delete(bf, name)
+		delete(af, name)
+	}
+
+	b := &ports.TaskState{Digest: digest(*before), Fields: map[string]json.RawMessage{}}
+	a := &ports.TaskState{Digest: digest(*after), Fields: map[string]json.RawMessage{}}
+	for name := range union(bf, af) {
+		if !bytes.Equal(bf[name], af[name]) {
+			b.Fields[name] = orNull(bf[name])
+			a.Fields[name] = orNull(af[name])
+		}
+	}
+	return b, a, nil
+}
+
+func fullState(t *domain.Task) (*ports.TaskState, error) {
+	if t == nil {
+		return nil, nil
+	}
+	fields, err := taskFields(*t)
+	if err != nil {
+		return nil, err
+	}
+	return &ports.TaskState{Digest: digest(*t), Fields: fields}, nil
+}
+
+// digest identifies the state of a task, leaving out the history and the update time that a revert moves on.
+func digest(t domain.Task) string {
+	t.History = nil
+	t.UpdatedAt = ""
+	b, _ := json.Marshal(t) // a Task always encodes
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// taskFields splits a task into its JSON fields.
+func taskFields(t domain.Task) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	return fields, json.Unmarshal(b, &fields)
+}
+
+// restore lays fields over base; null fields are cleared.
+func restore(base domain.Task, fields map[string]json.RawMessage) (domain.Task, error) {
+	merged, err := taskFields(base)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	for name, v := range fields {
+		merged[name] = v
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	var t domain.Task
+	return t, json.Unmarshal(b, &t)
+}
+
+func union(a, b map[string]json.RawMessage) map[string]bool {
+	res := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		res[k] = true
+	}
+	for k := range b {
+		res[k] = true
+	}
+	return res
+}
+
+func orNull(v json.RawMessage) json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+// lookup returns a copy of the stored task, or nil if there is none.
+func lookup(tx ports.TaskStore, id int) (*domain.Task, error) {
+	t, err := tx.Get(id)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t = t.Clone()
+	return &t, nil
+}
+
+// Journal returns the commands that can be undone and redone, most recent last.
+func (s *TaskService) Journal() (ports.JournalStacks, error) {
+	if s.journal == nil {
+		return ports.JournalStacks{}, errNoJournal
+	}
+	return s.journal.Load()
+}
+
+// Undo reverts the tasks changed by the most recent command and returns that command.
+// It fails with *domain.ConflictError if one of them changed since.
+func (s *TaskService) Undo() (ports.JournalEntry, error) {
+	return s.replay(true)
+}
+
+// Redo applies the most recently undone command again and returns it.
+func (s *TaskService) Redo() (ports.JournalEntry, error) {
+	return s.replay(false)
+}
+
+// replay moves the top entry of one stack to the other, putting its tasks back in the state on the far side of it.
+// The entry is updated with the states the tasks reached, so that it can be replayed the other way later.
+// Like journaled, it holds the journal before locking the store and only saves the stacks once the store committed.
+func (s *TaskService) replay(undo bool) (ports.JournalEntry, error) {
+	if s.journal == nil {
+		return ports.JournalEntry{}, errNoJournal
+	}
+
+	var entry ports.JournalEntry
+	err := s.journal.Update(func(stacks *ports.JournalStacks) error {
+		return s.raw.Atomic(func(tx ports.TaskStore) error {
+			var err error
+			entry, err = s.replayTop(tx, stacks, undo)
+			return err
+		})
+	})
+
+	return entry, err
+}
+
+// replayTop reverts or replays the top entry of the undo or redo stack within tx and moves it to the other stack.
+func (s *TaskService) replayTop(tx ports.TaskStore, stacks *ports.JournalStacks, undo bool) (ports.JournalEntry, error) {
+	from, to, verb := &stacks.Undo, &stacks.Redo, "undo"
+	if !undo {
+		from, to, verb = &stacks.Redo, &stacks.Undo, "redo"
+	}
+	if len(*from) == 0 {
+		return ports.JournalEntry{}, &domain.ValidationError{Msg: "nothing to " + verb}
+	}
+	entry := (*from)[len(*from)-1]
+	if entry.Barrier {
+		return ports.JournalEntry{}, &domain.ValidationError{Msg: fmt.Sprintf("cannot %s past %q: tasks moved to or from the archive are not journaled", verb, entry.Command)}
+	}
+	entry.Changes = append([]ports.TaskChange(nil), entry.Changes...) // left untouched if the replay fails
+
+	for i := range entry.Changes {
+		c := &entry.Changes[i]
+		if undo {
+			c = &entry.Changes[len(entry.Changes)-1-i]
+		}
+
+		expected, target := c.After, c.Before
+		if !undo {
+			expected, target = c.Before, c.After
+		}
+		reached, err := s.revert(tx, c.ID, expected, target)
+		if err != nil {
+			var conflict *domain.ConflictError
+			if errors.As(err, &conflict) {
+				return ports.JournalEntry{}, &domain.ConflictError{Msg: fmt.Sprintf("cannot %s %q: %v", verb, entry.Command, err)}
+			}
+			return ports.JournalEntry{}, err
+		}
+
+		var state *ports.TaskState
+		if reached != nil {
+			state = &ports.TaskState{Digest: digest(*reached)}
+		}
+		if undo {
+			c.Before = rebased(c.Before, state)
+		} else {
+			c.After = rebased(c.After, state)
+		}
+	}
+
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, entry)
+	for _, c := range entry.Changes {
+		reached := c.After
+		if undo {
+			reached = c.Before
+		}
+		rebase(*from, c.ID, reached, undo)
+	}
+	return entry, nil
+}
+
+// rebase lets the next entry of stack that changed task id expect the state the task was just put in.
+// The content matches what it recorded; the revision moved on.
+func rebase(stack []ports.JournalEntry, id int, reached *ports.TaskState, undo bool) {
+	for i := len(stack) - 1; i >= 0; i-- {
+		for j, c := range stack[i].Changes {
+			if c.ID != id {
+				continue
+			}
+			if undo {
+				stack[i].Changes[j].After = rebased(c.After, reached)
+			} else {
+				stack[i].Changes[j].Before = rebased(c.Before, reached)
+			}
+			return
+		}
+	}
+}
+
+// rebased is state with the digest of reached, a state of the same content; nil if the task is gone.
+func rebased(state, reached *ports.TaskState) *ports.TaskState {
+	if reached == nil {
+		return nil
+	}
+	res := &ports.TaskState{Digest: reached.Digest}
+	if state != nil {
+		res.Fields = state.Fields
+	}
+	return res
+}
+
+// revert puts task id in the target state, provided it is still in the expected one.
+// The history is kept and records the reverted fields, like any other change.
+func (s *TaskService) revert(tx ports.TaskStore, id int, expected, target *ports.TaskState) (*domain.Task, error) {
+	if (expected != nil && expected.Digest == "") || (target != nil && target.Digest == "") {
+		return nil, &domain.ConflictError{Msg: fmt.Sprintf("task %d was journaled by an older version", id)}
+	}
+	current, err := lookup(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if (current == nil) != (expected == nil) || (current != nil && digest(*current) != expected.Digest) {
+		return nil, &domain.ConflictError{Msg: fmt.Sprintf("task %d changed since", id)}
+	}
+
+	switch {
+	case target == nil:
+		return nil, tx.Delete(id)
+	case current == nil:
+		t, err := restore(domain.Task{}, target.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Insert(t); err != nil {
+			return nil, err
+		}
+	default:
+		t, err := restore(*current, target.Fields)
+		if err != nil {
+			return nil, err
+		}
+		t.Revision = current.Revision
+		t.History = current.History
+		t.UpdatedAt = domain.NowIso()
+		if err := s.update(tx, *current, &t); err != nil {
+			return nil, err
+		}
+	}
+
+	return lookup(tx, id)
+}
+
+var errNoJournal = errors.New("no journal configured")
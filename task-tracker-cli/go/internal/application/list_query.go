@@ -0,0 +1,112 @@
+package application
+
+import (
+	"fmt"
+	"sort"
+	"taskcli/internal/domain"
+	"time"
+)
+
+// SortOrder selects how List orders its results
+type SortOrder string
+
+const (
+	SortByID       SortOrder = "id"
+	SortByPriority SortOrder = "priority"
+)
+
+// ParseSortOrder parses a string into a SortOrder; the name of one of fields sorts by its values
+func ParseSortOrder(fields domain.FieldSet, s string) (SortOrder, error) {
+	switch s {
+	case "", string(SortByID):
+		return SortByID, nil
+	case string(SortByPriority):
+		return SortByPriority, nil
+	}
+	if _, ok := fields.Lookup(s); ok {
+		return SortOrder(s), nil
+	}
+
+	expected := "id|priority"
+	for _, d := range fields.Defs() {
+		expected += "|" + d.Name
+	}
+	return "", &domain.ValidationError{Msg: fmt.Sprintf("invalid sort %q (expected: %s)", s, expected)}
+}
+
+// ListQuery filters and orders the tasks returned by List.
+// The zero value lists every task by ID.
+type ListQuery struct {
+	Status    *domain.TaskStatus
+	Overdue   bool   // only unfinished tasks whose due date has passed
+	DueBefore string // only tasks due strictly before this date (YYYY-MM-DD)
+	Tags      TagFilter
+	Project   string // only tasks of this project; domain.DefaultProject for the unassigned ones
+	Assignee  string // only tasks assigned to this user; domain.NoAssignee for the unassigned ones
+	Fields    FieldFilter
+	Sort      SortOrder
+}
+
+// matches reports whether t passes the query's filters (Status is applied by the store).
+func (q ListQuery) matches(w domain.Workflow, t *domain.Task, now time.Time) bool {
+	if q.Overdue && !t.IsOverdue(w, now) {
+		return false
+	}
+	if q.DueBefore != "" && (t.Due == "" || t.Due >= q.DueBefore) {
+		return false
+	}
+	if !q.Tags.Matches(t) {
+		return false
+	}
+	if q.Project != "" && t.ProjectName() != q.Project {
+		return false
+	}
+	switch q.Assignee {
+	case "":
+	case domain.NoAssignee:
+		if len(t.Assignees) > 0 {
+			return false
+		}
+	default:
+		if !t.IsAssignedTo(q.Assignee) {
+			return false
+		}
+	}
+	if !q.Fields.Matches(t) {
+		return false
+	}
+
+	return true
+}
+
+// sortTasks orders tasks in place according to order, which may name one of fields; ties are always broken by ID.
+func sortTasks(tasks []domain.Task, order SortOrder, fields domain.FieldSet) {
+	switch order {
+	case SortByPriority:
+		sort.SliceStable(tasks, func(i, j int) bool {
+			ri, rj := tasks[i].Priority.Rank(), tasks[j].Priority.Rank()
+			if ri != rj {
+				return ri > rj
+			}
+			return tasks[i].ID < tasks[j].ID
+		})
+	default:
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+		if def, ok := fields.Lookup(string(order)); ok {
+			sortByField(tasks, def)
+		}
+	}
+}
+
+// sortByField orders tasks by the values of a custom field; tasks without it come last.
+// tasks must already be in ID order, which breaks ties.
+func sortByField(tasks []domain.Task, def domain.FieldDef) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, aSet := tasks[i].Fields[def.Name]
+		b, bSet := tasks[j].Fields[def.Name]
+		if aSet != bSet {
+			return aSet
+		}
+		return aSet && def.Compare(a, b) < 0
+	})
+}
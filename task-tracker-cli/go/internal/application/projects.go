@@ -0,0 +1,250 @@
+package application
+
+import (
+	"fmt"
+	"sort"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+)
+
+// WithDefaultProject sets the project that Add puts tasks in when none is given.
+// It must exist when a task is added; an empty name means domain.DefaultProject.
+func WithDefaultProject(name string) Option {
+	return func(s *TaskService) { s.project = name }
+}
+
+// ProjectSummary is a project with the number of its live tasks.
+type ProjectSummary struct {
+	domain.Project
+	Open  int // not closed
+	Total int
+}
+
+// CreateProject adds a named project.
+func (s *TaskService) CreateProject(name string) (domain.Project, error) {
+	p, err := domain.NewProject(name, s.now())
+	if err != nil {
+		return domain.Project{}, err
+	}
+
+	err = s.raw.Atomic(func(tx ports.TaskStore) error {
+		if exists, err := projectExists(tx, p.Name); err != nil || exists {
+			if err == nil {
+				err = &domain.ValidationError{Msg: fmt.Sprintf("project %s already exists", p.Name)}
+			}
+			return err
+		}
+		return tx.SaveProject(p)
+	})
+	if err != nil {
+		return domain.Project{}, err
+	}
+
+	return p, nil
+}
+
+// Projects lists the default project followed by the named ones, with their task counts.
+func (s *TaskService) Projects() ([]ProjectSummary, error) {
+	var res []ProjectSummary
+	err := s.store.Atomic(func(tx ports.TaskStore) error {
+		projects, err := tx.Projects()
+		if err != nil {
+			return err
+		}
+		tasks, err := tx.List()
+		if err != nil {
+			return err
+		}
+
+		res = []ProjectSummary{{Project: domain.Project{Name: domain.DefaultProject}}}
+		index := map[string]int{domain.DefaultProject: 0}
+		for _, p := range projects {
+			index[p.Name] = len(res)
+			res = append(res, ProjectSummary{Project: p})
+		}
+		for _, t := range tasks {
+			i, ok := index[t.ProjectName()]
+			if !ok {
+				continue
+			}
+			res[i].Total++
+			if !s.workflow.IsClosed(t.Status) {
+				res[i].Open++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(res[1:], func(i, j int) bool { return res[i+1].Name < res[j+1].Name })
+	return res, nil
+}
+
+// RenameProject renames a project and moves its tasks, including trashed and archived ones.
+// Project changes cannot be undone, so they bypass the journal.
+// Like Archive, it writes the archive first: if that fails nothing is renamed, and if the active store
+// fails afterwards, a retry finds no archived task left in the old project and completes the rename.
+func (s *TaskService) RenameProject(oldName, newName string) error {
+	if oldName == domain.DefaultProject {
+		return &domain.ValidationError{Msg: fmt.Sprintf("project %s cannot be renamed", domain.DefaultProject)}
+	}
+	renamed, err := domain.NewProject(newName, s.now())
+	if err != nil {
+		return err
+	}
+
+	err = s.raw.Atomic(func(tx ports.TaskStore) error {
+		p, err := findProject(tx, oldName)
+		if err != nil {
+			return err
+		}
+		if exists, err := projectExists(tx, renamed.Name); err != nil || exists {
+			if err == nil {
+				err = &domain.ValidationError{Msg: fmt.Sprintf("project %s already exists", renamed.Name)}
+			}
+			return err
+		}
+
+		if s.archive != nil {
+			err := s.archive.Atomic(func(atx ports.TaskStore) error {
+				return s.moveProjectTasks(atx, p.Name, renamed.Name)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		renamed.CreatedAt = p.CreatedAt
+		if err := tx.SaveProject(renamed); err != nil {
+			return err
+		}
+		if err := tx.DeleteProject(p.Name); err != nil {
+			return err
+		}
+		return s.moveProjectTasks(tx, p.Name, renamed.Name)
+	})
+	return err
+}
+
+// DeleteProject removes a project and returns the tasks it trashed.
+// A project that still has live tasks is only deleted with cascade, which moves them to the trash;
+// tasks that were already trashed or archived go back to the default project if they are ever restored.
+func (s *TaskService) DeleteProject(name string, cascade bool) ([]domain.Task, error) {
+	if name == domain.DefaultProject {
+		return nil, &domain.ValidationError{Msg: fmt.Sprintf("project %s cannot be deleted", domain.DefaultProject)}
+	}
+
+	var trashed []domain.Task
+	err := s.raw.Atomic(func(tx ports.TaskStore) error {
+		if _, err := findProject(tx, name); err != nil {
+			return err
+		}
+		tasks, err := liveStore{tx}.List()
+		if err != nil {
+			return err
+		}
+
+		inProject := map[int]bool{}
+		for _, t := range tasks {
+			if t.ProjectName() == name {
+				inProject[t.ID] = true
+			}
+		}
+		if len(inProject) > 0 && !cascade {
+			return &domain.ValidationError{Msg: fmt.Sprintf("project %s has %d tasks (move them to the trash with --cascade)", name, len(inProject))}
+		}
+
+		for _, t := range tasks {
+			if !inProject[t.ID] {
+				if t.ParentID != 0 && inProject[t.ParentID] {
+					return &domain.ValidationError{Msg: fmt.Sprintf("task %d of project %s has subtask %d in project %s", t.ParentID, name, t.ID, t.ProjectName())}
+				}
+				continue
+			}
+
+			before := t.Clone()
+			if err := t.Trash(s.now()); err != nil {
+				return err
+			}
+			if err := s.update(tx, before, &t); err != nil {
+				return err
+			}
+			trashed = append(trashed, t)
+		}
+
+		return tx.DeleteProject(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trashed, nil
+}
+
+// moveProjectTasks moves every task of project from to project to, recording it in their history.
+func (s *TaskService) moveProjectTasks(tx ports.TaskStore, from, to string) error {
+	tasks, err := tx.List()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		if t.ProjectName() != from {
+			continue
+		}
+
+		before := t.Clone()
+		if err := t.SetProject(to); err != nil {
+			return err
+		}
+		if err := s.update(tx, before, &t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rehome moves t to the default project when its project was deleted in the meantime.
+func rehome(tx ports.TaskStore, t *domain.Task) error {
+	exists, err := projectExists(tx, t.ProjectName())
+	if err != nil || exists {
+		return err
+	}
+	return t.SetProject(domain.DefaultProject)
+}
+
+// findProject looks up a named project; the default project is not stored and never found.
+func findProject(tx ports.TaskStore, name string) (domain.Project, error) {
+	projects, err := tx.Projects()
+	if err != nil {
+		return domain.Project{}, err
+	}
+
+	for _, p := range projects {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return domain.Project{}, &domain.NotFoundError{Msg: fmt.Sprintf("project %s does not exist", name)}
+}
+
+// requireProject fails with a NotFoundError unless name is the default project or a named one.
+func requireProject(tx ports.TaskStore, name string) error {
+	if name == domain.DefaultProject {
+		return nil
+	}
+
+	_, err := findProject(tx, name)
+	return err
+}
+
+// projectExists reports whether name is the default project or a named one.
+func projectExists(tx ports.TaskStore, name string) (bool, error) {
+	err := requireProject(tx, name)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,85 @@
+package application
+
+import (
+	"sort"
+	"strings"
+	"taskcli/internal/domain"
+)
+
+// TagFilter selects tasks by tag.
+// Each clause must hold (AND); a clause holds if any of its terms holds (OR);
+// a negated term holds if the task does NOT carry the tag.
+type TagFilter [][]tagTerm
+
+type tagTerm struct {
+	tag    string
+	negate bool
+}
+
+// ParseTagFilter builds a filter from expressions such as "backend", "api|cli" or "!blocked".
+// Every expression becomes one AND clause; "|" or "," separates OR alternatives.
+func ParseTagFilter(exprs []string) (TagFilter, error) {
+	var f TagFilter
+	for _, expr := range exprs {
+		var clause []tagTerm
+		for _, raw := range strings.FieldsFunc(expr, func(r rune) bool { return r == '|' || r == ',' }) {
+			raw = strings.TrimSpace(raw)
+			negate := strings.HasPrefix(raw, "!")
+
+			tag, err := domain.NormalizeTag(strings.TrimPrefix(raw, "!"))
+			if err != nil {
+				return nil, err
+			}
+			clause = append(clause, tagTerm{tag: tag, negate: negate})
+		}
+
+		if len(clause) == 0 {
+			return nil, &domain.ValidationError{Msg: "tag filter cannot be empty"}
+		}
+		f = append(f, clause)
+	}
+
+	return f, nil
+}
+
+// Matches reports whether t satisfies every clause of the filter
+func (f TagFilter) Matches(t *domain.Task) bool {
+	for _, clause := range f {
+		ok := false
+		for _, term := range clause {
+			if t.HasTag(term.tag) != term.negate {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TagCount is a tag together with the number of tasks carrying it
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// countTags tallies tags across tasks, ordered by tag name
+func countTags(tasks []domain.Task) []TagCount {
+	counts := map[string]int{}
+	for _, t := range tasks {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+
+	res := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		res = append(res, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Tag < res[j].Tag })
+
+	return res
+}
@@ -1,128 +1,601 @@
 package application
 
 import (
-	"sort"
+	"errors"
+	"fmt"
 	"taskcli/internal/domain"
 	"taskcli/internal/ports"
+	"time"
 )
 
 // TaskService holds use-cases. No knowledge of file/JSON
-type TaskService struct{ repo ports.TaskRepository }
+type TaskService struct {
+	store    ports.TaskStore // live tasks only; see liveStore
+	all      ports.TaskStore // including the trash
+	raw      ports.TaskStore // like all, but not journaled
+	moving   ports.TaskStore // like store, but also handing out tasks in undeclared statuses; see Move
+	archive  ports.TaskStore // nil unless WithArchive is given
+	journal  ports.Journal   // nil unless WithJournal is given
+	command  string          // recorded with journal entries
+	project  string          // given to new tasks without a project; see WithDefaultProject
+	workflow domain.Workflow // statuses and transitions; see WithWorkflow
+	fields   domain.FieldSet // custom fields tasks may carry; see WithFields
+	autoDone bool            // complete a task once its whole checklist is checked
+	now      func() time.Time
+	actor    string // recorded in task history
+}
+
+// Option customizes a TaskService.
+type Option func(*TaskService)
 
-func NewTaskService(r ports.TaskRepository) *TaskService {
-	return &TaskService{repo: r}
+// WithClock replaces time.Now, e.g. to make due-date logic deterministic in tests.
+func WithClock(now func() time.Time) Option {
+	return func(s *TaskService) { s.now = now }
 }
 
-func nextID(tasks []domain.Task) int {
-	max := 0
-	for _, t := range tasks {
-		if t.ID > max {
-			max = t.ID
-		}
+// WithActor sets who is recorded in the history of the tasks this service changes.
+func WithActor(name string) Option {
+	return func(s *TaskService) { s.actor = name }
+}
+
+// WithFields declares the custom fields that SetFields, Replace and List accept; none by default.
+func WithFields(fields domain.FieldSet) Option {
+	return func(s *TaskService) { s.fields = fields }
+}
+
+func NewTaskService(s ports.TaskStore, opts ...Option) *TaskService {
+	svc := &TaskService{now: time.Now, workflow: domain.DefaultWorkflow()}
+	svc.raw = declaredStore{TaskStore: s, svc: svc}
+	svc.all = journalStore{TaskStore: svc.raw, svc: svc}
+	svc.store = liveStore{svc.all}
+	svc.moving = liveStore{journalStore{TaskStore: s, svc: svc}}
+	for _, opt := range opts {
+		opt(svc)
 	}
 
-	return max + 1
+	return svc
+}
+
+// AddOptions carries the optional attributes of a new task.
+// Zero values keep the domain defaults.
+type AddOptions struct {
+	Priority   domain.Priority
+	Due        string // YYYY-MM-DD
+	Tags       []string
+	ParentID   int // 0 for a top-level task
+	BlockedBy  []int
+	Recurrence string // see domain.ParseRecurrence
+	Project    string // defaults to the parent's project for a subtask, else to WithDefaultProject
+	Estimate   string // see domain.ParseEstimate
 }
 
 func (s *TaskService) Add(description string) (*domain.Task, error) {
-	tasks, err := s.repo.Load()
+	return s.AddWith(description, AddOptions{})
+}
+
+// AddWith allocates the next ID from the store's sequence and inserts the task in one unit of work,
+// so concurrent invocations cannot hand out the same ID and deleted IDs are never reused.
+func (s *TaskService) AddWith(description string, opts AddOptions) (*domain.Task, error) {
+	var task *domain.Task
+	err := s.store.Atomic(func(tx ports.TaskStore) error {
+		id, err := tx.NextID()
+		if err != nil {
+			return err
+		}
+
+		task, err = domain.NewTask(id, description, s.workflow.InitialStatus())
+		if err != nil {
+			return err
+		}
+
+		if opts.Priority != "" {
+			if err := task.SetPriority(opts.Priority); err != nil {
+				return err
+			}
+		}
+		if err := task.SetDue(opts.Due); err != nil {
+			return err
+		}
+		if err := task.UpdateTags(opts.Tags, nil); err != nil {
+			return err
+		}
+		if err := task.SetRecurrence(opts.Recurrence); err != nil {
+			return err
+		}
+		if err := task.SetEstimate(opts.Estimate); err != nil {
+			return err
+		}
+		project := opts.Project
+		if opts.ParentID != 0 {
+			parent, err := tx.Get(opts.ParentID)
+			if err != nil {
+				return parentError(opts.ParentID, err)
+			}
+			if err := task.SetParent(opts.ParentID); err != nil {
+				return err
+			}
+			if project == "" {
+				project = parent.ProjectName()
+			}
+		}
+		if project == "" {
+			project = s.project
+		}
+		if project != "" {
+			if err := requireProject(tx, project); err != nil {
+				return projectError(err)
+			}
+			if err := task.SetProject(project); err != nil {
+				return err
+			}
+		}
+		for _, b := range opts.BlockedBy {
+			// A brand-new task has no dependents, so existence is the only thing that can go wrong
+			if _, err := tx.Get(b); err != nil {
+				return blockerError(b, err)
+			}
+			if err := task.AddBlocker(b); err != nil {
+				return err
+			}
+		}
+
+		task.RecordCreated(s.now(), s.actor)
+		return tx.Insert(*task)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	id := nextID(tasks)
+	return task, nil
+}
 
-	task, err := domain.NewTask(id, description)
+// History returns the recorded changes of a task, oldest first.
+func (s *TaskService) History(id int) ([]domain.Change, error) {
+	t, err := s.store.Get(id)
 	if err != nil {
 		return nil, err
 	}
 
-	tasks = append(tasks, *task)
-	if err := s.repo.Save(tasks); err != nil {
-		return nil, err
+	return t.History, nil
+}
+
+// Comment adds a comment by the service actor to task id.
+func (s *TaskService) Comment(id int, text string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.AddComment(text, s.now(), s.actor)
+	})
+}
+
+// Get returns a single task, including the revision it is currently at.
+func (s *TaskService) Get(id int) (domain.Task, error) {
+	return s.store.Get(id)
+}
+
+// Replace stores an edited copy of a task obtained from Get.
+// It fails with *domain.ConflictError if the task changed since that copy was read.
+// The history cannot be edited this way; the changes are appended to it instead.
+func (s *TaskService) Replace(task domain.Task) error {
+	if err := task.Validate(s.workflow, s.fields); err != nil {
+		return err
 	}
 
-	return task, nil
+	return s.store.Atomic(func(tx ports.TaskStore) error {
+		stored, err := tx.Get(task.ID)
+		if err != nil {
+			return err
+		}
+
+		task.History = stored.History
+		return s.update(tx, stored, &task)
+	})
 }
 
 func (s *TaskService) Update(id int, desc string) error {
-	return s.withTask(id, func(t *domain.Task) error {
-		return t.UpdateDescription(desc)
+	return s.UpdateWith(id, UpdateOptions{Description: desc})
+}
+
+// UpdateOptions carries the attributes UpdateWith changes; zero values leave them as they are.
+type UpdateOptions struct {
+	Description string
+	Project     string // an existing project, or domain.DefaultProject
+	Revision    int    // only update while the task is still at this revision
+}
+
+// UpdateWith changes the description and/or the project of a task in one step.
+func (s *TaskService) UpdateWith(id int, opts UpdateOptions) error {
+	return s.withTaskTx(id, func(tx ports.TaskStore, t *domain.Task) error {
+		if opts.Revision > 0 && t.Revision != opts.Revision {
+			return domain.NewConflictError(id, opts.Revision, t.Revision)
+		}
+		if opts.Description != "" {
+			if err := t.UpdateDescription(opts.Description); err != nil {
+				return err
+			}
+		}
+		if opts.Project != "" {
+			if err := requireProject(tx, opts.Project); err != nil {
+				return projectError(err)
+			}
+			if err := t.SetProject(opts.Project); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
+// Delete moves a task to the trash, from which Restore brings it back until it is purged.
+// Tasks with subtasks cannot be deleted, so no subtask is left without its parent.
+// Tasks blocked by the deleted task no longer wait for it, but keep the reference in case it is restored.
 func (s *TaskService) Delete(id int) error {
-	tasks, err := s.repo.Load()
-	if err != nil {
+	return s.withTaskTx(id, func(tx ports.TaskStore, t *domain.Task) error {
+		tasks, err := tx.List()
+		if err != nil {
+			return err
+		}
+		if n := len(domain.Children(tasks, id)); n > 0 {
+			return &domain.ValidationError{Msg: fmt.Sprintf("task %d has %d subtasks; delete or move them first", id, n)}
+		}
+
+		return t.Trash(s.now())
+	})
+}
+
+// MarkInProgress starts a task. A task whose blockers are not closed yet is only started when force is set.
+func (s *TaskService) MarkInProgress(id int, force bool) error {
+	_, err := s.Transition(id, domain.TransitionStart, force)
+	return err
+}
+
+// MarkDone completes a task. A task with unfinished subtasks is only completed when force is set.
+func (s *TaskService) MarkDone(id int, force bool) error {
+	_, err := s.Complete(id, force)
+	return err
+}
+
+// Complete is MarkDone that also returns the next occurrence it created for a recurring task
+// (nil if the task does not recur, its series has ended or it was already done).
+func (s *TaskService) Complete(id int, force bool) (*domain.Task, error) {
+	return s.Transition(id, domain.TransitionDone, force)
+}
+
+// Transition moves a task along the named transition of the service workflow (see WithWorkflow).
+// force overrides the checks that span tasks: open blockers when entering an active status, open subtasks when completing.
+// Closing a task stops its timer; completing a recurring task creates and returns its next occurrence.
+func (s *TaskService) Transition(id int, name string, force bool) (*domain.Task, error) {
+	tr, ok := s.workflow.FindTransition(name)
+	if !ok {
+		return nil, &domain.ValidationError{Msg: fmt.Sprintf("unknown transition %q", name)}
+	}
+
+	return s.transition(s.store, id, force, func(*domain.Task) (domain.Transition, error) {
+		return tr, nil
+	})
+}
+
+// Move puts a task in the given status through the first transition of the service workflow that leads there.
+// It returns the transition taken and, like Transition, the next occurrence of a completed recurring task.
+// Unlike other commands, Move accepts tasks in a status the workflow does not declare, so that they can be moved on.
+func (s *TaskService) Move(id int, status domain.TaskStatus, force bool) (domain.Transition, *domain.Task, error) {
+	var taken domain.Transition
+	next, err := s.transition(s.moving, id, force, func(t *domain.Task) (domain.Transition, error) {
+		var err error
+		taken, err = s.workflow.TransitionTo(t.Status, status)
+		return taken, err
+	})
+	return taken, next, err
+}
+
+// transition applies the transition chosen by pick for the task in store, guarding what spans tasks.
+func (s *TaskService) transition(store ports.TaskStore, id int, force bool, pick func(*domain.Task) (domain.Transition, error)) (*domain.Task, error) {
+	var next *domain.Task
+	err := s.withTaskIn(store, id, func(tx ports.TaskStore, t *domain.Task) error {
+		tr, err := pick(t)
+		if err != nil {
+			return err
+		}
+
+		next, err = s.applyTransition(tx, t, tr, force)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// applyTransition moves t along tr within the unit of work tx; the caller stores t.
+func (s *TaskService) applyTransition(tx ports.TaskStore, t *domain.Task, tr domain.Transition, force bool) (*domain.Task, error) {
+	var (
+		tasks []domain.Task
+		err   error
+	)
+	if !force && t.Status != tr.To {
+		if tasks, err = tx.List(); err != nil {
+			return nil, err
+		}
 	}
 
-	idx := -1
-	for i := range tasks {
-		if tasks[i].ID == id {
-			idx = i
-			break
+	if s.workflow.IsDone(tr.To) && tasks != nil {
+		if open := domain.OpenDescendants(s.workflow, tasks, t.ID); len(open) > 0 && tr.Allows(t.Status) {
+			return nil, &domain.ValidationError{Msg: fmt.Sprintf("task %d has %d open subtasks (use --force to complete it anyway)", t.ID, len(open))}
 		}
 	}
 
-	if idx == -1 {
-		return &domain.NotFoundError{Msg: "task not found"}
+	var openBlockers []int
+	if tasks != nil {
+		openBlockers = domain.OpenBlockers(s.workflow, tasks, *t)
+	}
+
+	wasDone := s.workflow.IsDone(t.Status)
+	if err := t.ApplyTransition(s.workflow, tr, openBlockers); err != nil {
+		return nil, err
+	}
+
+	if _, running := t.ActiveTimer(); running && s.workflow.IsClosed(t.Status) {
+		if _, err := t.StopTimer(s.now()); err != nil {
+			return nil, err
+		}
+	}
+	if !s.workflow.IsDone(t.Status) || wasDone || t.Recurrence == "" {
+		return nil, nil
 	}
 
-	tasks = append(tasks[:idx], tasks[idx+1:]...)
+	return s.nextOccurrence(tx, t)
+}
+
+// Recur sets the recurrence rule of a task; an empty rule stops the series after this occurrence.
+func (s *TaskService) Recur(id int, rule string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.SetRecurrence(rule)
+	})
+}
+
+// SetParent moves a task under parentID, or to the top level when parentID is 0.
+func (s *TaskService) SetParent(id, parentID int) error {
+	return s.withTaskTx(id, func(tx ports.TaskStore, t *domain.Task) error {
+		tasks, err := tx.List()
+		if err != nil {
+			return err
+		}
+		if err := domain.ValidateParent(tasks, id, parentID); err != nil {
+			return err
+		}
+
+		return t.SetParent(parentID)
+	})
+}
+
+// Block records that task id cannot start before each of blockerIDs is done.
+func (s *TaskService) Block(id int, blockerIDs ...int) error {
+	return s.withTaskTx(id, func(tx ports.TaskStore, t *domain.Task) error {
+		tasks, err := tx.List()
+		if err != nil {
+			return err
+		}
 
-	return s.repo.Save(tasks)
+		for _, b := range blockerIDs {
+			if err := domain.ValidateBlocker(tasks, id, b); err != nil {
+				return err
+			}
+			if err := t.AddBlocker(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (s *TaskService) MarkInProgress(id int) error {
+// Unblock removes blockerIDs from the blockers of task id.
+func (s *TaskService) Unblock(id int, blockerIDs ...int) error {
 	return s.withTask(id, func(t *domain.Task) error {
-		return t.MarkInProgress()
+		for _, b := range blockerIDs {
+			t.RemoveBlocker(b)
+		}
+		return nil
 	})
 }
 
-func (s *TaskService) MarkDone(id int) error {
+func (s *TaskService) Prioritize(id int, p domain.Priority) error {
 	return s.withTask(id, func(t *domain.Task) error {
-		return t.MarkDone()
+		return t.SetPriority(p)
+	})
+}
+
+// SetDue sets the due date (YYYY-MM-DD) of a task; an empty date clears it.
+func (s *TaskService) SetDue(id int, due string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.SetDue(due)
+	})
+}
+
+// Tag adds and removes tags on a task.
+func (s *TaskService) Tag(id int, add, remove []string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.UpdateTags(add, remove)
+	})
+}
+
+// Assign adds users to the assignees of a task.
+func (s *TaskService) Assign(id int, users ...string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.Assign(users...)
+	})
+}
+
+// Unassign removes users from the assignees of a task; without users, everyone is removed.
+func (s *TaskService) Unassign(id int, users ...string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.Unassign(users...)
+	})
+}
+
+// Estimate sizes a task in points or as a duration; "none" removes the estimate.
+func (s *TaskService) Estimate(id int, estimate string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.SetEstimate(estimate)
+	})
+}
+
+// SetFields applies "key=value" assignments of custom fields to a task; an empty value removes the field.
+// Either every assignment is applied or none is.
+func (s *TaskService) SetFields(id int, assignments ...string) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		for _, a := range assignments {
+			name, value, err := domain.ParseFieldAssignment(a)
+			if err != nil {
+				return err
+			}
+			if err := t.SetField(s.fields, name, value); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
-func (s *TaskService) List(filter *domain.TaskStatus) ([]domain.Task, error) {
-	tasks, err := s.repo.Load()
+// Tags lists every tag in use with the number of tasks carrying it.
+func (s *TaskService) Tags() ([]TagCount, error) {
+	tasks, err := s.store.List()
 	if err != nil {
 		return nil, err
 	}
 
-	if filter == nil {
-		sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
-		return tasks, nil
+	return countTags(tasks), nil
+}
+
+// ParseDate resolves user date input (ISO or relative like "tomorrow") against the service clock.
+func (s *TaskService) ParseDate(input string) (string, error) {
+	return domain.ParseDueDate(input, s.now())
+}
+
+func (s *TaskService) List(q ListQuery) ([]domain.Task, error) {
+	if err := checkQuery(s.store, q); err != nil {
+		return nil, err
+	}
+	return s.listFrom(s.store, q)
+}
+
+// checkQuery rejects a query naming a project that does not exist or an invalid assignee.
+func checkQuery(store ports.TaskStore, q ListQuery) error {
+	if q.Project != "" {
+		if err := requireProject(store, q.Project); err != nil {
+			return err
+		}
+	}
+	if q.Assignee != "" && q.Assignee != domain.NoAssignee {
+		if _, err := domain.ParseAssignee(q.Assignee); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TaskService) listFrom(store ports.TaskStore, q ListQuery) ([]domain.Task, error) {
+	var (
+		tasks []domain.Task
+		err   error
+	)
+	if q.Status == nil {
+		tasks, err = store.List()
+	} else {
+		tasks, err = store.ListByStatus(*q.Status)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	now := s.now()
 	res := make([]domain.Task, 0, len(tasks))
 	for _, t := range tasks {
-		if t.Status == *filter {
+		if q.matches(s.workflow, &t, now) {
 			res = append(res, t)
 		}
 	}
 
-	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	sortTasks(res, q.Sort, s.fields)
 	return res, nil
 }
 
-func (s *TaskService) withTask(id int, fn func(t *domain.Task) error) error {
-	tasks, err := s.repo.Load()
+// nextOccurrence inserts the follow-up of the recurring task t, or returns nil once its series has ended.
+func (s *TaskService) nextOccurrence(tx ports.TaskStore, t *domain.Task) (*domain.Task, error) {
+	due, err := t.NextDue(s.now())
+	if err != nil || due == "" {
+		return nil, err
+	}
+
+	id, err := tx.NextID()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for i := range tasks {
-		if tasks[i].ID == id {
-			if err := fn(&tasks[i]); err != nil {
-				return err
-			}
+	next, err := t.NextOccurrence(id, due, s.workflow.InitialStatus())
+	if err != nil {
+		return nil, err
+	}
 
-			return s.repo.Save(tasks)
+	next.RecordCreated(s.now(), s.actor)
+	return next, tx.Insert(*next)
+}
+
+func (s *TaskService) withTask(id int, fn func(t *domain.Task) error) error {
+	return s.withTaskTx(id, func(_ ports.TaskStore, t *domain.Task) error {
+		return fn(t)
+	})
+}
+
+// withTaskTx is withTask for use-cases that also need to look at other tasks in the same unit of work.
+func (s *TaskService) withTaskTx(id int, fn func(tx ports.TaskStore, t *domain.Task) error) error {
+	return s.withTaskIn(s.store, id, fn)
+}
+
+// withTaskIn is withTaskTx on a store other than the live one.
+func (s *TaskService) withTaskIn(store ports.TaskStore, id int, fn func(tx ports.TaskStore, t *domain.Task) error) error {
+	return store.Atomic(func(tx ports.TaskStore) error {
+		task, err := tx.Get(id)
+		if err != nil {
+			return err
 		}
+
+		before := task.Clone()
+		if err := fn(tx, &task); err != nil {
+			return err
+		}
+
+		return s.update(tx, before, &task)
+	})
+}
+
+// update stores t after appending what changed since before to its history.
+func (s *TaskService) update(tx ports.TaskStore, before domain.Task, t *domain.Task) error {
+	t.RecordChanges(before, s.now(), s.actor)
+	return tx.Update(*t)
+}
+
+// blockerError reports a missing blocker as a validation problem of the blocked task.
+func blockerError(blockerID int, err error) error {
+	var nf *domain.NotFoundError
+	if errors.As(err, &nf) {
+		return &domain.ValidationError{Msg: fmt.Sprintf("blocking task %d does not exist", blockerID)}
+	}
+	return err
+}
+
+// projectError reports a missing project as a validation problem of the task put in it.
+func projectError(err error) error {
+	var nf *domain.NotFoundError
+	if errors.As(err, &nf) {
+		return &domain.ValidationError{Msg: nf.Msg}
 	}
+	return err
+}
 
-	return &domain.NotFoundError{Msg: "task not found"}
+// parentError reports a missing parent as a validation problem of the child.
+func parentError(parentID int, err error) error {
+	var nf *domain.NotFoundError
+	if errors.As(err, &nf) {
+		return &domain.ValidationError{Msg: fmt.Sprintf("parent task %d does not exist", parentID)}
+	}
+	return err
 }
@@ -2,15 +2,32 @@ package application
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"taskcli/internal/adapters/collection"
 	"taskcli/internal/domain"
+	"taskcli/internal/ports"
 	"testing"
+	"time"
 )
 
 // memRepo is an in-memory implementation of TaskRepository used in tests.
+// Services reach it through the collection shim, just like fsrepo in production.
 type memRepo struct {
-	tasks []domain.Task
+	tasks    []domain.Task
+	lastID   int
+	projects []domain.Project
 }
 
+func (m *memRepo) LastID() int { return m.lastID }
+
+func (m *memRepo) SetLastID(id int) { m.lastID = id }
+
+func (m *memRepo) Projects() []domain.Project { return append([]domain.Project(nil), m.projects...) }
+
+func (m *memRepo) SetProjects(p []domain.Project) { m.projects = append([]domain.Project(nil), p...) }
+
 func (m *memRepo) Load() ([]domain.Task, error) {
 	return append([]domain.Task(nil), m.tasks...), nil
 }
@@ -22,7 +39,7 @@ func (m *memRepo) Save(t []domain.Task) error {
 
 func TestAddTask(t *testing.T) {
 	repo := &memRepo{}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
 	task, err := svc.Add("Buy tomato")
 	if err != nil {
@@ -37,9 +54,31 @@ func TestAddTask(t *testing.T) {
 	}
 }
 
+func TestAddTask_DoesNotReuseDeletedID(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo))
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.Add("task"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := svc.Delete(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task, err := svc.Add("after delete")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.ID != 4 {
+		t.Errorf("expected ID 4, got %d", task.ID)
+	}
+}
+
 func TestAddTask_EmptyDescription_ShouldFail(t *testing.T) {
 	repo := &memRepo{}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
 	task, err := svc.Add("")
 	if err == nil {
@@ -66,7 +105,7 @@ func TestUpdateTask(t *testing.T) {
 			{ID: 1, Description: "Buy tomato", Status: domain.StatusTodo},
 		},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 	err := svc.Update(1, "Buy 2kg tomato")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -79,7 +118,7 @@ func TestUpdateTask(t *testing.T) {
 
 func TestUpdateNotFound(t *testing.T) {
 	repo := &memRepo{}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
 	err := svc.Update(42, "Do I exist?")
 	if err == nil {
@@ -97,9 +136,9 @@ func TestMarkInProgress(t *testing.T) {
 			{ID: 1, Description: "A", Status: domain.StatusTodo},
 		},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
-	if err := svc.MarkInProgress(1); err != nil {
+	if err := svc.MarkInProgress(1, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -114,9 +153,9 @@ func TestMarkDone(t *testing.T) {
 			{ID: 1, Description: "A", Status: domain.StatusTodo},
 		},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
-	if err := svc.MarkDone(1); err != nil {
+	if err := svc.MarkDone(1, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -132,17 +171,21 @@ func TestDelete(t *testing.T) {
 			{ID: 2, Description: "B", Status: domain.StatusTodo},
 		},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
 	if err := svc.Delete(1); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(repo.tasks) != 1 {
-		t.Fatalf("expected 1 task remaining, got %d", len(repo.tasks))
+	tasks, err := svc.List(ListQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(tasks), []int{2}) {
+		t.Fatalf("expected only task 2 to be listed, got %v", ids(tasks))
 	}
-	if repo.tasks[0].ID != 2 {
-		t.Errorf("expected remaining task ID 2, got %d", repo.tasks[0].ID)
+	if len(repo.tasks) != 2 || !repo.tasks[0].IsTrashed() {
+		t.Errorf("expected task 1 to be kept in the trash, got %+v", repo.tasks)
 	}
 }
 
@@ -150,7 +193,7 @@ func TestDeleteNotFound(t *testing.T) {
 	repo := &memRepo{
 		tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo}},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
 	err := svc.Delete(999)
 	if err == nil {
@@ -169,9 +212,9 @@ func TestListAllSortedByID(t *testing.T) {
 			{ID: 2, Description: "B", Status: domain.StatusInProgress},
 		},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
-	got, err := svc.List(nil)
+	got, err := svc.List(ListQuery{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -192,10 +235,10 @@ func TestListFiltered(t *testing.T) {
 			{ID: 3, Description: "C", Status: domain.StatusDone},
 		},
 	}
-	svc := NewTaskService(repo)
+	svc := NewTaskService(collection.New(repo))
 
 	filter := domain.StatusDone
-	got, err := svc.List(&filter)
+	got, err := svc.List(ListQuery{Status: &filter})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -210,3 +253,1985 @@ func TestListFiltered(t *testing.T) {
 		t.Errorf("expected done IDs [1,3], got [%d,%d]", got[0].ID, got[1].ID)
 	}
 }
+
+func TestReplace_StaleRevision_ShouldConflict(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: 1}},
+	}
+	svc := NewTaskService(collection.New(repo))
+
+	stale, err := svc.Get(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Someone else edits the task in between
+	if err := svc.Update(1, "B"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stale.Description = "C"
+	err = svc.Replace(stale)
+
+	var conflictErr *domain.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected ConflictError, got %T: %v", err, err)
+	}
+	if repo.tasks[0].Description != "B" {
+		t.Errorf("expected description %q to survive, got %q", "B", repo.tasks[0].Description)
+	}
+}
+
+func TestReplace_CurrentRevision(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: 1}},
+	}
+	svc := NewTaskService(collection.New(repo))
+
+	task, _ := svc.Get(1)
+	task.Description = "B"
+	if err := svc.Replace(task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if repo.tasks[0].Description != "B" || repo.tasks[0].Revision != 2 {
+		t.Errorf("expected description B at revision 2, got %+v", repo.tasks[0])
+	}
+}
+
+func TestAddWithPriority(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo))
+
+	task, err := svc.AddWith("Fix prod", AddOptions{Priority: domain.PriorityCritical})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Priority != domain.PriorityCritical || repo.tasks[0].Priority != domain.PriorityCritical {
+		t.Errorf("expected critical priority, got %q", repo.tasks[0].Priority)
+	}
+}
+
+func TestAddTask_DefaultsToMediumPriority(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo))
+
+	if _, err := svc.Add("Buy tomato"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.tasks[0].Priority != domain.PriorityMedium {
+		t.Errorf("expected medium priority, got %q", repo.tasks[0].Priority)
+	}
+}
+
+func TestPrioritize(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Priority: domain.PriorityMedium}},
+	}
+	svc := NewTaskService(collection.New(repo))
+
+	if err := svc.Prioritize(1, domain.PriorityHigh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.tasks[0].Priority != domain.PriorityHigh {
+		t.Errorf("expected high priority, got %q", repo.tasks[0].Priority)
+	}
+
+	err := svc.Prioritize(1, "urgent")
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+}
+
+func TestListSortedByPriorityThenID(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "A", Status: domain.StatusTodo, Priority: domain.PriorityLow},
+			{ID: 2, Description: "B", Status: domain.StatusTodo, Priority: domain.PriorityHigh},
+			{ID: 3, Description: "C", Status: domain.StatusTodo, Priority: domain.PriorityCritical},
+			{ID: 4, Description: "D", Status: domain.StatusTodo, Priority: domain.PriorityHigh},
+			{ID: 5, Description: "E", Status: domain.StatusTodo},
+		},
+	}
+	svc := NewTaskService(collection.New(repo))
+
+	got, err := svc.List(ListQuery{Sort: SortByPriority})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []int{3, 2, 4, 5, 1}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Fatalf("expected order %v, got %v", want, ids(got))
+		}
+	}
+}
+
+func ids(tasks []domain.Task) []int {
+	res := make([]int, len(tasks))
+	for i, t := range tasks {
+		res[i] = t.ID
+	}
+	return res
+}
+
+func fixedClock() time.Time {
+	return time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+}
+
+func TestSetDue(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo}},
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	due, err := svc.ParseDate("tomorrow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.SetDue(1, due); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if repo.tasks[0].Due != "2025-03-13" {
+		t.Errorf("expected due 2025-03-13, got %q", repo.tasks[0].Due)
+	}
+}
+
+func TestListOverdue(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "late", Status: domain.StatusTodo, Due: "2025-03-10"},
+			{ID: 2, Description: "late but done", Status: domain.StatusDone, Due: "2025-03-10"},
+			{ID: 3, Description: "due today", Status: domain.StatusTodo, Due: "2025-03-12"},
+			{ID: 4, Description: "no due date", Status: domain.StatusTodo},
+			{ID: 5, Description: "late in progress", Status: domain.StatusInProgress, Due: "2025-03-01"},
+		},
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	got, err := svc.List(ListQuery{Overdue: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 5 {
+		t.Errorf("expected overdue IDs [1 5], got %v", ids(got))
+	}
+}
+
+func TestListDueBefore(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "A", Status: domain.StatusTodo, Due: "2025-03-14"},
+			{ID: 2, Description: "B", Status: domain.StatusTodo, Due: "2025-03-15"},
+			{ID: 3, Description: "C", Status: domain.StatusDone, Due: "2025-03-01"},
+			{ID: 4, Description: "D", Status: domain.StatusTodo},
+		},
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	got, err := svc.List(ListQuery{DueBefore: "2025-03-15"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 3 {
+		t.Errorf("expected IDs [1 3], got %v", ids(got))
+	}
+}
+
+func taggedRepo() *memRepo {
+	return &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "API", Status: domain.StatusTodo, Tags: []string{"backend"}},
+			{ID: 2, Description: "Blocked API", Status: domain.StatusTodo, Tags: []string{"backend", "blocked"}},
+			{ID: 3, Description: "Terraform", Status: domain.StatusTodo, Tags: []string{"infra"}},
+			{ID: 4, Description: "README", Status: domain.StatusTodo, Tags: []string{"docs"}},
+			{ID: 5, Description: "Untagged", Status: domain.StatusTodo},
+		},
+	}
+}
+
+func TestListByTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		exprs []string
+		want  []int
+	}{
+		{"single tag", []string{"backend"}, []int{1, 2}},
+		{"AND", []string{"backend", "blocked"}, []int{2}},
+		{"OR", []string{"backend|infra"}, []int{1, 2, 3}},
+		{"OR with comma", []string{"infra,docs"}, []int{3, 4}},
+		{"NOT", []string{"!backend"}, []int{3, 4, 5}},
+		{"AND NOT", []string{"backend", "!blocked"}, []int{1}},
+		{"case-insensitive", []string{"BACKEND", "!Blocked"}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTaskService(collection.New(taggedRepo()))
+
+			filter, err := ParseTagFilter(tt.exprs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := svc.List(ListQuery{Tags: filter})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ids(got), tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, ids(got))
+			}
+		})
+	}
+}
+
+func TestParseTagFilter_Invalid_ShouldFail(t *testing.T) {
+	for _, expr := range []string{"", "|", "!", "bad tag"} {
+		_, err := ParseTagFilter([]string{expr})
+
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%q: expected ValidationError, got %T: %v", expr, err, err)
+		}
+	}
+}
+
+func TestTag(t *testing.T) {
+	repo := taggedRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	if err := svc.Tag(2, []string{"urgent"}, []string{"blocked"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"backend", "urgent"}; !reflect.DeepEqual(repo.tasks[1].Tags, want) {
+		t.Errorf("expected %v, got %v", want, repo.tasks[1].Tags)
+	}
+}
+
+func TestTagsCounts(t *testing.T) {
+	svc := NewTaskService(collection.New(taggedRepo()))
+
+	got, err := svc.Tags()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []TagCount{{"backend", 2}, {"blocked", 1}, {"docs", 1}, {"infra", 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func hierarchyRepo() *memRepo {
+	return &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "Epic", Status: domain.StatusTodo},
+			{ID: 2, Description: "Story A", Status: domain.StatusTodo, ParentID: 1},
+			{ID: 3, Description: "Story B", Status: domain.StatusDone, ParentID: 1},
+			{ID: 4, Description: "Subtask", Status: domain.StatusDone, ParentID: 2},
+			{ID: 5, Description: "Standalone", Status: domain.StatusTodo},
+		},
+		lastID: 5,
+	}
+}
+
+func TestAddWithParent(t *testing.T) {
+	repo := hierarchyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	task, err := svc.AddWith("Subtask", AddOptions{ParentID: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.ParentID != 2 {
+		t.Errorf("expected parent 2, got %d", task.ParentID)
+	}
+
+	_, err = svc.AddWith("Orphan", AddOptions{ParentID: 42})
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for missing parent, got %T: %v", err, err)
+	}
+}
+
+func TestMarkDone_WithOpenSubtasks_ShouldFailUnlessForced(t *testing.T) {
+	repo := hierarchyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	err := svc.MarkDone(1, false)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	if repo.tasks[0].Status != domain.StatusTodo {
+		t.Fatalf("parent must stay open, got %q", repo.tasks[0].Status)
+	}
+
+	if err := svc.MarkDone(1, true); err != nil {
+		t.Fatalf("unexpected error with force: %v", err)
+	}
+	if repo.tasks[0].Status != domain.StatusDone {
+		t.Errorf("expected parent done, got %q", repo.tasks[0].Status)
+	}
+}
+
+func TestSetParent_Cycle_ShouldFail(t *testing.T) {
+	repo := hierarchyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	err := svc.SetParent(1, 4)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+
+	if err := svc.SetParent(5, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.tasks[4].ParentID != 1 {
+		t.Errorf("expected task 5 under 1, got %d", repo.tasks[4].ParentID)
+	}
+}
+
+func TestDelete_WithSubtasks_ShouldFail(t *testing.T) {
+	repo := hierarchyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	err := svc.Delete(2)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	if len(repo.tasks) != 5 {
+		t.Errorf("expected nothing deleted, got %d tasks", len(repo.tasks))
+	}
+}
+
+func TestTree(t *testing.T) {
+	svc := NewTaskService(collection.New(hierarchyRepo()))
+
+	nodes, err := svc.Tree(ListQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct{ id, depth, percent int }{
+		{1, 0, 66}, {2, 1, 100}, {4, 2, -1}, {3, 1, -1}, {5, 0, -1},
+	}
+	if len(nodes) != len(want) {
+		t.Fatalf("expected %d nodes, got %+v", len(want), nodes)
+	}
+	for i, w := range want {
+		n := nodes[i]
+		if n.Task.ID != w.id || n.Depth != w.depth || n.Percent() != w.percent {
+			t.Errorf("node %d: expected id=%d depth=%d percent=%d, got id=%d depth=%d percent=%d",
+				i, w.id, w.depth, w.percent, n.Task.ID, n.Depth, n.Percent())
+		}
+	}
+}
+
+func TestTree_FilteredParentBecomesRoot(t *testing.T) {
+	svc := NewTaskService(collection.New(hierarchyRepo()))
+
+	filter := domain.StatusDone
+	nodes, err := svc.Tree(ListQuery{Status: &filter})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(nodes) != 2 || nodes[0].Task.ID != 3 || nodes[1].Task.ID != 4 || nodes[1].Depth != 0 {
+		t.Errorf("expected done tasks 3 and 4 as roots, got %+v", nodes)
+	}
+}
+
+// 1 (done) <- 2 <- 3; 4 waits for 2 and 3; 5 and 6 are independent, 6 is critical
+func dependencyRepo() *memRepo {
+	return &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "Design", Status: domain.StatusDone, Priority: domain.PriorityMedium},
+			{ID: 2, Description: "Build", Status: domain.StatusTodo, Priority: domain.PriorityMedium, BlockedBy: []int{1}},
+			{ID: 3, Description: "Deploy", Status: domain.StatusTodo, Priority: domain.PriorityMedium, BlockedBy: []int{2}},
+			{ID: 4, Description: "Announce", Status: domain.StatusTodo, Priority: domain.PriorityMedium, BlockedBy: []int{2, 3}},
+			{ID: 5, Description: "Chore", Status: domain.StatusTodo, Priority: domain.PriorityMedium},
+			{ID: 6, Description: "Hotfix", Status: domain.StatusTodo, Priority: domain.PriorityCritical},
+		},
+		lastID: 6,
+	}
+}
+
+func TestMarkInProgress_Blocked_ShouldFailUnlessForced(t *testing.T) {
+	repo := dependencyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	err := svc.MarkInProgress(3, false)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+
+	if err := svc.MarkInProgress(2, false); err != nil {
+		t.Fatalf("blocker of 2 is done, unexpected error: %v", err)
+	}
+	if err := svc.MarkInProgress(3, true); err != nil {
+		t.Fatalf("unexpected error with force: %v", err)
+	}
+	if repo.tasks[2].Status != domain.StatusInProgress {
+		t.Errorf("expected task 3 in progress, got %q", repo.tasks[2].Status)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	repo := dependencyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	if err := svc.Block(5, 6, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.tasks[4].BlockedBy; len(got) != 2 || got[0] != 1 || got[1] != 6 {
+		t.Errorf("expected blockers [1 6], got %v", got)
+	}
+
+	err := svc.Block(2, 4)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a cycle, got %T: %v", err, err)
+	}
+
+	if err := svc.Unblock(5, 6); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.tasks[4].BlockedBy; len(got) != 1 || got[0] != 1 {
+		t.Errorf("expected blockers [1], got %v", got)
+	}
+}
+
+func TestAddWithBlockers(t *testing.T) {
+	svc := NewTaskService(collection.New(dependencyRepo()))
+
+	task, err := svc.AddWith("Retro", AddOptions{BlockedBy: []int{4, 3}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(task.BlockedBy) != 2 || task.BlockedBy[0] != 3 {
+		t.Errorf("expected blockers [3 4], got %v", task.BlockedBy)
+	}
+
+	_, err = svc.AddWith("Orphan", AddOptions{BlockedBy: []int{42}})
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a missing blocker, got %T: %v", err, err)
+	}
+}
+
+func TestDelete_ReleasesDependents(t *testing.T) {
+	repo := dependencyRepo()
+	svc := NewTaskService(collection.New(repo))
+
+	if err := svc.Delete(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tasks, _ := svc.List(ListQuery{})
+	announce, _ := svc.Get(4)
+	if open := domain.OpenBlockers(domain.DefaultWorkflow(), tasks, announce); !reflect.DeepEqual(open, []int{2}) {
+		t.Errorf("expected the deleted blocker to stop blocking, got %v", open)
+	}
+
+	if _, err := svc.Purge(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	announce, _ = svc.Get(4)
+	if !reflect.DeepEqual(announce.BlockedBy, []int{2}) {
+		t.Errorf("expected the purged blocker to be dropped, got %v", announce.BlockedBy)
+	}
+}
+
+func TestReady(t *testing.T) {
+	svc := NewTaskService(collection.New(dependencyRepo()))
+
+	ready, err := svc.Ready()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 2 unblocks two tasks, then 6 outranks 5 by priority
+	if got := ids(ready); !reflect.DeepEqual(got, []int{2, 6, 5}) {
+		t.Errorf("expected ready queue [2 6 5], got %v", got)
+	}
+}
+
+func TestDeps(t *testing.T) {
+	svc := NewTaskService(collection.New(dependencyRepo()))
+
+	g, err := svc.Deps(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var up, down []int
+	for _, n := range g.BlockedBy {
+		up = append(up, n.Task.ID)
+	}
+	for _, n := range g.Blocks {
+		down = append(down, n.Task.ID)
+	}
+	if !reflect.DeepEqual(up, []int{2, 1}) || g.BlockedBy[1].Depth != 1 {
+		t.Errorf("expected 3 to wait for 2 then 1, got %+v", g.BlockedBy)
+	}
+	if !reflect.DeepEqual(down, []int{4}) {
+		t.Errorf("expected 3 to block 4, got %+v", g.Blocks)
+	}
+
+	_, err = svc.Deps(42)
+	var notFound *domain.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError, got %T: %v", err, err)
+	}
+}
+
+func TestDeps_SharedDependenciesAreListedOnce(t *testing.T) {
+	// 1 waits for 2 and 3, which both wait for 4, which waits for 5
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Release", Status: domain.StatusTodo, BlockedBy: []int{2, 3}},
+		{ID: 2, Description: "Docs", Status: domain.StatusTodo, BlockedBy: []int{4}},
+		{ID: 3, Description: "Tests", Status: domain.StatusTodo, BlockedBy: []int{4}},
+		{ID: 4, Description: "API", Status: domain.StatusTodo, BlockedBy: []int{5}},
+		{ID: 5, Description: "Schema", Status: domain.StatusTodo},
+	}}
+	svc := NewTaskService(collection.New(repo))
+
+	g, err := svc.Deps(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []DepNode{
+		{Task: repo.tasks[1], Depth: 0},
+		{Task: repo.tasks[3], Depth: 1},
+		{Task: repo.tasks[4], Depth: 2},
+		{Task: repo.tasks[2], Depth: 0},
+		{Task: repo.tasks[3], Depth: 1, Repeat: true},
+	}
+	if !reflect.DeepEqual(g.BlockedBy, want) {
+		t.Errorf("expected %+v, got %+v", want, g.BlockedBy)
+	}
+
+	// a ladder of 20 pairs, each task waiting for both tasks of the next pair
+	var ladder []domain.Task
+	for id := 1; id <= 40; id++ {
+		task := domain.Task{ID: id, Description: "Step", Status: domain.StatusTodo}
+		if next := (id+1)/2*2 + 1; next < 40 {
+			task.BlockedBy = []int{next, next + 1}
+		}
+		ladder = append(ladder, task)
+	}
+	svc = NewTaskService(collection.New(&memRepo{tasks: ladder}))
+	if g, err = svc.Deps(1); err != nil || len(g.BlockedBy) > 2*len(ladder) {
+		t.Errorf("expected at most one line per edge, got %d lines, %v", len(g.BlockedBy), err)
+	}
+}
+
+func TestComplete_Recurring_CreatesNextOccurrence(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "Review dashboards", Status: domain.StatusTodo, Priority: domain.PriorityMedium, Due: "2025-03-10", Recurrence: "weekly"},
+		},
+		lastID: 3,
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	next, err := svc.Complete(1, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next == nil {
+		t.Fatalf("expected a next occurrence")
+	}
+	if next.ID != 4 || next.Due != "2025-03-17" || next.SeriesID != 1 {
+		t.Errorf("expected task 4 due 2025-03-17 in series 1, got %+v", next)
+	}
+	if len(repo.tasks) != 2 || repo.tasks[0].Status != domain.StatusDone {
+		t.Fatalf("expected done task plus its follow-up, got %+v", repo.tasks)
+	}
+
+	// Completing an already done occurrence must not spawn another one
+	if next, err := svc.Complete(1, false); err != nil || next != nil {
+		t.Errorf("expected no new occurrence, got %+v, %v", next, err)
+	}
+	if len(repo.tasks) != 2 {
+		t.Errorf("expected 2 tasks, got %d", len(repo.tasks))
+	}
+}
+
+func TestComplete_NotRecurring(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "Once", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	next, err := svc.Complete(1, false)
+	if err != nil || next != nil {
+		t.Fatalf("expected no occurrence, got %+v, %v", next, err)
+	}
+	if len(repo.tasks) != 1 {
+		t.Errorf("expected 1 task, got %d", len(repo.tasks))
+	}
+}
+
+func TestRecur_InvalidRule_ShouldFail(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "Chore", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo))
+
+	err := svc.Recur(1, "FREQ=HOURLY")
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+
+	if err := svc.Recur(1, "FREQ=WEEKLY;INTERVAL=1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.tasks[0].Recurrence != "weekly" {
+		t.Errorf("expected canonical rule \"weekly\", got %q", repo.tasks[0].Recurrence)
+	}
+}
+
+func TestTimers_OnlyOneRunning(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Write report", Status: domain.StatusTodo},
+		{ID: 2, Description: "Review PR", Status: domain.StatusTodo},
+	}}
+	now := fixedClock()
+	svc := NewTaskService(collection.New(repo), WithClock(func() time.Time { return now }))
+
+	if err := svc.StartTimer(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := svc.StartTimer(2)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a second timer, got %T: %v", err, err)
+	}
+
+	now = now.Add(40 * time.Minute)
+	task, elapsed, err := svc.StopTimer()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.ID != 1 || elapsed != 40*time.Minute {
+		t.Errorf("expected 40m on task 1, got %s on task %d", elapsed, task.ID)
+	}
+
+	if _, _, err := svc.StopTimer(); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError when nothing runs, got %T: %v", err, err)
+	}
+	if err := svc.StartTimer(2); err != nil {
+		t.Fatalf("unexpected error after stop: %v", err)
+	}
+}
+
+func TestComplete_StopsRunningTimer(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "Write report", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	if err := svc.StartTimer(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.MarkDone(1, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, running := repo.tasks[0].ActiveTimer(); running {
+		t.Errorf("expected timer to be stopped when the task is done")
+	}
+}
+
+func TestTimesheet(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Write report", Status: domain.StatusDone, TimeEntries: []domain.TimeEntry{
+			{Start: "2025-03-10T09:00:00Z", End: "2025-03-10T10:00:00Z"}, // Monday
+			{Start: "2025-03-12T13:00:00Z", End: "2025-03-12T13:30:00Z"}, // Wednesday
+			{Start: "2025-03-03T09:00:00Z", End: "2025-03-03T12:00:00Z"}, // previous week
+		}},
+		{ID: 2, Description: "Review PR", Status: domain.StatusInProgress, TimeEntries: []domain.TimeEntry{
+			{Start: "2025-03-12T07:00:00Z"}, // running until the clock's 09:00
+		}},
+		{ID: 3, Description: "Idle", Status: domain.StatusTodo},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	sheet, err := svc.Timesheet(fixedClock())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := sheet.Days[0].Format(domain.DateLayout); got != "2025-03-10" {
+		t.Errorf("expected week to start on Monday 2025-03-10, got %s", got)
+	}
+	if len(sheet.Rows) != 2 || sheet.Rows[0].Task.ID != 2 || sheet.Rows[1].Task.ID != 1 {
+		t.Fatalf("expected rows for tasks 2 then 1, got %+v", sheet.Rows)
+	}
+
+	report := sheet.Rows[1]
+	if report.Days[0] != time.Hour || report.Days[2] != 30*time.Minute || report.Total != 90*time.Minute {
+		t.Errorf("unexpected per-day split %v (total %s)", report.Days, report.Total)
+	}
+	if sheet.DayTotals[2] != 150*time.Minute || sheet.Total != 210*time.Minute {
+		t.Errorf("expected Wednesday 2h30m and week 3h30m, got %s and %s", sheet.DayTotals[2], sheet.Total)
+	}
+}
+
+func TestTimesheet_AcrossADSTChange(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	// clocks moved from midnight to 01:00 on Tuesday 2022-03-22, so that Tuesday had 23 hours
+	wednesday := time.Date(2022, 3, 23, 0, 30, 0, 0, tehran)
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo, TimeEntries: []domain.TimeEntry{
+			{Start: wednesday.UTC().Format(time.RFC3339), End: wednesday.Add(time.Hour).UTC().Format(time.RFC3339)},
+		}},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(func() time.Time { return wednesday.Add(2 * time.Hour) }))
+
+	sheet, err := svc.Timesheet(wednesday)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sheet.Rows) != 1 || sheet.Rows[0].Days[2] != time.Hour {
+		t.Errorf("expected the hour on Wednesday, got %+v", sheet.Rows)
+	}
+}
+
+func TestHistory_RecordsChangesWithActor(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithActor("alice"))
+
+	if _, err := svc.Add("Buy milk"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Update(1, "Buy oat milk"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.MarkDone(1, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, err := svc.History(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var fields []string
+	for _, c := range history {
+		fields = append(fields, c.Field)
+		if c.Actor != "alice" || c.At != "2025-03-12T09:00:00Z" {
+			t.Errorf("unexpected actor or time in %+v", c)
+		}
+	}
+	if !reflect.DeepEqual(fields, []string{domain.FieldCreated, "description", "status"}) {
+		t.Errorf("unexpected history %+v", history)
+	}
+	if last := history[2]; last.Old != "todo" || last.New != "done" {
+		t.Errorf("expected todo -> done, got %+v", last)
+	}
+}
+
+func TestReplace_CannotRewriteHistory(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo), WithActor("alice"))
+	_, _ = svc.Add("Buy milk")
+
+	task, _ := svc.Get(1)
+	task.History = nil
+	_ = task.UpdateDescription("Buy oat milk")
+	if err := svc.Replace(task); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, _ := svc.History(1)
+	if len(history) != 2 || history[0].Field != domain.FieldCreated || history[1].Field != "description" {
+		t.Errorf("expected history to be appended to, got %+v", history)
+	}
+}
+
+func TestTransition_CancelAndReopen(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "Spike", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	if err := svc.StartTimer(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Transition(1, domain.TransitionCancel, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.tasks[0].Status != domain.StatusCancelled {
+		t.Fatalf("expected cancelled, got %q", repo.tasks[0].Status)
+	}
+	if _, running := repo.tasks[0].ActiveTimer(); running {
+		t.Errorf("expected timer to stop when the task is cancelled")
+	}
+
+	if _, err := svc.Transition(1, domain.TransitionReopen, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.tasks[0].Status != domain.StatusTodo {
+		t.Errorf("expected todo after reopen, got %q", repo.tasks[0].Status)
+	}
+
+	_, err := svc.Transition(1, "teleport", false)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for an unknown transition, got %T: %v", err, err)
+	}
+}
+
+func TestTransition_CancelledTasksDoNotHoldUpOthers(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Epic", Status: domain.StatusTodo},
+		{ID: 2, Description: "Dropped story", Status: domain.StatusCancelled, ParentID: 1},
+		{ID: 3, Description: "Abandoned prerequisite", Status: domain.StatusCancelled},
+		{ID: 4, Description: "Follow-up", Status: domain.StatusTodo, BlockedBy: []int{3}},
+	}}
+	svc := NewTaskService(collection.New(repo))
+
+	if err := svc.MarkDone(1, false); err != nil {
+		t.Errorf("cancelled subtask should not block completion: %v", err)
+	}
+	if err := svc.MarkInProgress(4, false); err != nil {
+		t.Errorf("cancelled blocker should not block starting: %v", err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Prerequisite", Status: domain.StatusTodo},
+		{ID: 2, Description: "Follow-up", Status: domain.StatusTodo, BlockedBy: []int{1}},
+	}}
+	svc := NewTaskService(collection.New(repo))
+
+	tr, _, err := svc.Move(1, domain.StatusInReview, false)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a move the workflow does not allow, got %T: %v", err, err)
+	}
+
+	if _, _, err := svc.Move(2, domain.StatusInProgress, false); !errors.As(err, &validationErr) {
+		t.Fatalf("expected open blockers to prevent the move, got %T: %v", err, err)
+	}
+	if _, _, err := svc.Move(2, domain.StatusInProgress, true); err != nil {
+		t.Fatalf("unexpected error with force: %v", err)
+	}
+
+	if tr, _, err = svc.Move(1, domain.StatusDone, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.Name != domain.TransitionDone || repo.tasks[0].Status != domain.StatusDone {
+		t.Errorf("expected the move to take %s, got %q and status %q", domain.TransitionDone, tr.Name, repo.tasks[0].Status)
+	}
+}
+
+func TestMove_CustomWorkflow(t *testing.T) {
+	w := domain.Workflow{
+		Statuses: []domain.StatusDef{
+			{Name: "backlog"},
+			{Name: "doing", Category: domain.CategoryActive},
+			{Name: "shipped", Category: domain.CategoryDone},
+		},
+		Transitions: []domain.Transition{
+			{Name: "pick", From: []domain.TaskStatus{"backlog"}, To: "doing"},
+			{Name: "ship", From: []domain.TaskStatus{"doing"}, To: "shipped"},
+		},
+	}
+	w, err := domain.NewWorkflow(w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithWorkflow(w))
+	epic, err := svc.Add("Epic")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.AddWith("Story", AddOptions{ParentID: epic.ID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if epic.Status != "backlog" {
+		t.Fatalf("expected new tasks in backlog, got %q", epic.Status)
+	}
+
+	if _, err := svc.Transition(epic.ID, "pick", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, err = svc.Move(epic.ID, "shipped", false)
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected the open subtask to prevent shipping, got %T: %v", err, err)
+	}
+	if _, _, err := svc.Move(epic.ID, "shipped", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !w.IsClosed(repo.tasks[0].Status) {
+		t.Errorf("expected shipped to count as closed")
+	}
+}
+
+func TestWorkflow_UndeclaredStatusIsReported(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo},
+		{ID: 2, Description: "B", Status: "doing", Due: "2025-03-01"},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	var validationErr *domain.ValidationError
+	if _, err := svc.Get(2); !errors.As(err, &validationErr) || !strings.Contains(err.Error(), `task 2 has status "doing"`) {
+		t.Errorf("expected Get to report the undeclared status, got %T: %v", err, err)
+	}
+	if tasks, err := svc.List(ListQuery{}); err != nil || len(tasks) != 2 {
+		t.Errorf("expected the listing to keep both tasks, got %v, %v", tasks, err)
+	}
+	if ready, err := svc.Ready(); err != nil || len(ready) != 1 || ready[0].ID != 1 {
+		t.Errorf("expected only task 1 to be ready, got %v, %v", ready, err)
+	}
+
+	w, err := domain.NewWorkflow(domain.Workflow{Statuses: []domain.StatusDef{
+		{Name: domain.StatusTodo},
+		{Name: "doing", Category: domain.CategoryActive},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kanban := NewTaskService(collection.New(repo), WithClock(fixedClock), WithWorkflow(w))
+	overdue, err := kanban.List(ListQuery{Overdue: true})
+	if err != nil || len(overdue) != 1 || overdue[0].ID != 2 {
+		t.Errorf("expected task 2 to be overdue once doing is declared, got %v, %v", overdue, err)
+	}
+}
+
+func TestMove_OutOfAStatusTheNewWorkflowDropped(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo},
+		{ID: 2, Description: "B", Status: domain.StatusInProgress},
+		{ID: 3, Description: "C", Status: domain.StatusDone},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithWorkflow(kanbanWorkflow(t)))
+
+	var validationErr *domain.ValidationError
+	if _, err := svc.Transition(2, "ship", false); !errors.As(err, &validationErr) {
+		t.Fatalf("expected named transitions to refuse an undeclared status, got %T: %v", err, err)
+	}
+
+	for id, status := range map[int]domain.TaskStatus{1: "backlog", 2: "doing", 3: "shipped"} {
+		tr, _, err := svc.Move(id, status, false)
+		if err != nil {
+			t.Fatalf("move %d to %s: unexpected error: %v", id, status, err)
+		}
+		if tr.Message != "Task moved to "+string(status) {
+			t.Errorf("unexpected message %q", tr.Message)
+		}
+	}
+	if _, _, err := svc.Move(2, "todo", false); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for a status the workflow does not declare, got %T: %v", err, err)
+	}
+
+	tasks, err := svc.List(ListQuery{})
+	if err != nil || len(tasks) != 3 {
+		t.Fatalf("expected every task listed once moved on, got %v, %v", tasks, err)
+	}
+	if got := repo.tasks[1].History; len(got) != 1 || got[0].Old != string(domain.StatusInProgress) || got[0].New != "doing" {
+		t.Errorf("expected the move recorded in the history, got %+v", got)
+	}
+}
+
+// kanbanWorkflow is a custom workflow that declares none of the built-in statuses
+func kanbanWorkflow(t *testing.T) domain.Workflow {
+	t.Helper()
+	w, err := domain.NewWorkflow(domain.Workflow{
+		Statuses: []domain.StatusDef{
+			{Name: "backlog"},
+			{Name: "doing", Category: domain.CategoryActive},
+			{Name: "shipped", Category: domain.CategoryDone},
+		},
+		Transitions: []domain.Transition{
+			{Name: "pick", From: []domain.TaskStatus{"backlog"}, To: "doing"},
+			{Name: "ship", From: []domain.TaskStatus{"doing"}, To: "shipped"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return w
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Epic", Status: domain.StatusTodo},
+		{ID: 2, Description: "Story", Status: domain.StatusTodo, ParentID: 1},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	var validationErr *domain.ValidationError
+	if err := svc.Delete(1); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a task with subtasks, got %T: %v", err, err)
+	}
+	for _, id := range []int{2, 1} {
+		if err := svc.Delete(id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var notFound *domain.NotFoundError
+	if err := svc.Update(2, "Edited"); !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError for a trashed task, got %T: %v", err, err)
+	}
+
+	trashed, err := svc.Trash()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trashed) != 2 || trashed[0].DeletedAt != "2025-03-12T09:00:00Z" {
+		t.Fatalf("expected both tasks in the trash, got %+v", trashed)
+	}
+
+	if err := svc.Restore(2); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError while the parent is trashed, got %T: %v", err, err)
+	}
+	for _, id := range []int{1, 2} {
+		if err := svc.Restore(id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := svc.Restore(2); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a task that is not trashed, got %T: %v", err, err)
+	}
+
+	tasks, _ := svc.List(ListQuery{})
+	if !reflect.DeepEqual(ids(tasks), []int{1, 2}) {
+		t.Errorf("expected both tasks back, got %v", ids(tasks))
+	}
+	history, _ := svc.History(2)
+	if last := history[len(history)-1]; last.Field != "deletedAt" || last.New != "" {
+		t.Errorf("expected the restore to be recorded, got %+v", last)
+	}
+}
+
+func TestPurge_OlderThan(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Old", Status: domain.StatusTodo, DeletedAt: "2025-01-01T09:00:00Z"},
+		{ID: 2, Description: "Recent", Status: domain.StatusTodo, DeletedAt: "2025-03-10T09:00:00Z", ParentID: 1},
+		{ID: 3, Description: "Live", Status: domain.StatusTodo},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	purged, err := svc.Purge(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(purged), []int{1}) {
+		t.Fatalf("expected only the old task to be purged, got %v", ids(purged))
+	}
+
+	if _, err := svc.Get(1); err == nil {
+		t.Errorf("expected the purged task to be gone")
+	}
+	trashed, _ := svc.Trash()
+	if len(trashed) != 1 || trashed[0].ParentID != 0 {
+		t.Errorf("expected the recent task to stay in the trash without its purged parent, got %+v", trashed)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	closed := func(id int, status domain.TaskStatus, at string, parentID int) domain.Task {
+		return domain.Task{ID: id, Description: "T", Status: status, UpdatedAt: at, ParentID: parentID, Revision: 1}
+	}
+	repo := &memRepo{tasks: []domain.Task{
+		closed(1, domain.StatusDone, "2024-11-01T09:00:00Z", 0),
+		closed(2, domain.StatusCancelled, "2024-11-01T09:00:00Z", 0),
+		closed(3, domain.StatusDone, "2025-03-01T09:00:00Z", 0), // too recent
+		closed(4, domain.StatusDone, "2024-11-01T09:00:00Z", 0),
+		{ID: 5, Description: "Open subtask", Status: domain.StatusTodo, ParentID: 4},
+		closed(6, domain.StatusDone, "2024-11-01T09:00:00Z", 0),
+		closed(7, domain.StatusDone, "2024-11-01T09:00:00Z", 6), // archived together with its parent
+	}}
+	archive := &memRepo{}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithArchive(collection.New(archive)))
+
+	archived, err := svc.Archive(ArchiveQuery{Done: true, OlderThan: 90 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(archived), []int{1, 6, 7}) {
+		t.Fatalf("expected tasks 1, 6 and 7 to be archived, got %v", ids(archived))
+	}
+
+	active, _ := svc.List(ListQuery{})
+	if !reflect.DeepEqual(ids(active), []int{2, 3, 4, 5}) {
+		t.Errorf("expected the archived tasks to leave the active store, got %v", ids(active))
+	}
+	stored, _ := svc.ListArchived(ListQuery{})
+	if !reflect.DeepEqual(ids(stored), []int{1, 6, 7}) {
+		t.Errorf("expected the archived tasks in the archive, got %v", ids(stored))
+	}
+
+	var validationErr *domain.ValidationError
+	if err := svc.Unarchive(7); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError while the parent is archived, got %T: %v", err, err)
+	}
+	for _, id := range []int{6, 7} {
+		if err := svc.Unarchive(id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var notFound *domain.NotFoundError
+	if err := svc.Unarchive(7); !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError for a task that is not archived, got %T: %v", err, err)
+	}
+
+	active, _ = svc.List(ListQuery{})
+	if !reflect.DeepEqual(ids(active), []int{2, 3, 4, 5, 6, 7}) {
+		t.Errorf("expected tasks 6 and 7 back, got %v", ids(active))
+	}
+	if task, _ := svc.Get(7); task.ParentID != 6 || task.Revision != 1 {
+		t.Errorf("expected the unarchived task unchanged, got %+v", task)
+	}
+}
+
+func TestArchive_RequiresSelection(t *testing.T) {
+	svc := NewTaskService(collection.New(&memRepo{}), WithArchive(collection.New(&memRepo{})))
+
+	_, err := svc.Archive(ArchiveQuery{OlderThan: time.Hour})
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError without done or cancelled, got %T: %v", err, err)
+	}
+}
+
+func TestArchive_UndoStopsThere(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusDone, UpdatedAt: "2024-11-01T09:00:00Z", Revision: 1},
+		{ID: 2, Description: "B", Status: domain.StatusTodo, Revision: 1},
+	}}
+	archive := &memRepo{}
+	journal := &memJournal{}
+	run := func(command string) *TaskService {
+		return NewTaskService(collection.New(repo), WithClock(fixedClock), WithArchive(collection.New(archive)), WithJournal(journal, command))
+	}
+
+	if err := run("mark-done 2").MarkDone(2, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := run("archive --done").Archive(ArchiveQuery{Done: true, OlderThan: 24 * time.Hour}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	svc := run("undo")
+	var validationErr *domain.ValidationError
+	_, err := svc.Undo()
+	if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), `"archive --done"`) {
+		t.Fatalf("expected undo to stop at the archive, got %T: %v", err, err)
+	}
+	if task, _ := svc.Get(2); task.Status != domain.StatusDone {
+		t.Errorf("expected mark-done 2 to stay done behind the archive, got %q", task.Status)
+	}
+
+	if err := run("unarchive 1").Unarchive(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stacks, _ := svc.Journal()
+	if n := len(stacks.Undo); n != 3 || !stacks.Undo[n-1].Barrier || stacks.Undo[n-1].Command != "unarchive 1" {
+		t.Fatalf("expected the unarchive journaled as a barrier, got %+v", stacks.Undo)
+	}
+	if _, err := svc.Undo(); !errors.As(err, &validationErr) {
+		t.Errorf("expected undo to stop at the unarchive, got %T: %v", err, err)
+	}
+}
+
+// txOnlyStore hands out tasks only inside a unit of work, where no other invocation can change them
+type txOnlyStore struct{ ports.TaskStore }
+
+func (txOnlyStore) Get(id int) (domain.Task, error) {
+	return domain.Task{}, fmt.Errorf("task %d read outside a unit of work", id)
+}
+
+func TestUnarchive_ReadsInsideTheArchiveUnitOfWork(t *testing.T) {
+	repo := &memRepo{}
+	archive := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Epic", Status: domain.StatusDone},
+		{ID: 2, Description: "Story", Status: domain.StatusDone, ParentID: 1},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithArchive(txOnlyStore{collection.New(archive)}))
+
+	var validationErr *domain.ValidationError
+	if err := svc.Unarchive(2); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a subtask of an archived task, got %T: %v", err, err)
+	}
+	for _, id := range []int{1, 2} {
+		if err := svc.Unarchive(id); err != nil {
+			t.Fatalf("unarchive %d: unexpected error: %v", id, err)
+		}
+	}
+	var notFound *domain.NotFoundError
+	if err := svc.Unarchive(1); !errors.As(err, &notFound) {
+		t.Errorf("expected NotFoundError once unarchived, got %T: %v", err, err)
+	}
+	if len(repo.tasks) != 2 || len(archive.tasks) != 0 {
+		t.Errorf("expected both tasks back in the active store, got %v and %v", repo.tasks, archive.tasks)
+	}
+}
+
+func TestArchive_KeepsTasksInAStatusTheWorkflowDropped(t *testing.T) {
+	repo := &memRepo{}
+	archive := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: "shipped", Estimate: "3", UpdatedAt: "2025-03-10T09:00:00Z"},
+		{ID: 2, Description: "B", Status: domain.StatusDone, Estimate: "2", UpdatedAt: "2025-03-10T09:00:00Z"},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithArchive(collection.New(archive)))
+
+	if archived, err := svc.ListArchived(ListQuery{}); err != nil || len(archived) != 2 {
+		t.Fatalf("expected both archived tasks listed, got %v, %v", archived, err)
+	}
+	if _, err := svc.Velocity(VelocityQuery{Weeks: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := svc.Unarchive(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := svc.Move(1, domain.StatusDone, false); err != nil {
+		t.Fatalf("expected the unarchived task to be moved on, got %v", err)
+	}
+	if task, _ := svc.Get(1); task.Status != domain.StatusDone {
+		t.Errorf("expected task 1 done, got %q", task.Status)
+	}
+}
+
+// memJournal is an in-memory ports.Journal
+type memJournal struct{ stacks ports.JournalStacks }
+
+func (j *memJournal) Load() (ports.JournalStacks, error) { return j.stacks, nil }
+
+func (j *memJournal) Update(fn func(stacks *ports.JournalStacks) error) error {
+	stacks := j.stacks
+	stacks.Undo = append([]ports.JournalEntry(nil), stacks.Undo...)
+	stacks.Redo = append([]ports.JournalEntry(nil), stacks.Redo...)
+	if err := fn(&stacks); err != nil {
+		return err
+	}
+	j.stacks = stacks
+	return nil
+}
+
+func TestUndoRedo(t *testing.T) {
+	repo := &memRepo{}
+	journal := &memJournal{}
+	store := collection.New(repo)
+	run := func(command string) *TaskService {
+		return NewTaskService(store, WithClock(fixedClock), WithJournal(journal, command))
+	}
+
+	if _, err := run("add A").Add("A"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := run("mark-done 1").MarkDone(1, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := run("delete 1").Delete(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := run("list").List(ListQuery{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	svc := run("undo")
+	stacks, _ := svc.Journal()
+	if len(stacks.Undo) != 3 || stacks.Undo[2].Command != "delete 1" {
+		t.Fatalf("expected three journaled commands, got %+v", stacks.Undo)
+	}
+
+	for _, want := range []string{"delete 1", "mark-done 1"} {
+		entry, err := svc.Undo()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if entry.Command != want {
+			t.Errorf("expected to undo %q, got %q", want, entry.Command)
+		}
+	}
+	task, err := svc.Get(1)
+	if err != nil || task.Status != domain.StatusTodo {
+		t.Fatalf("expected task 1 back in todo, got %+v, %v", task, err)
+	}
+	if last := task.History[len(task.History)-1]; last.Field != "status" || last.New != string(domain.StatusTodo) {
+		t.Errorf("expected the undo to be recorded in the history, got %+v", last)
+	}
+
+	if entry, err := svc.Redo(); err != nil || entry.Command != "mark-done 1" {
+		t.Fatalf("expected to redo mark-done 1, got %q, %v", entry.Command, err)
+	}
+	if task, _ := svc.Get(1); task.Status != domain.StatusDone {
+		t.Errorf("expected task 1 done again, got %q", task.Status)
+	}
+
+	// a new command drops what was left to redo
+	if err := run("update 1").Update(1, "A2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var validationErr *domain.ValidationError
+	if _, err := svc.Redo(); !errors.As(err, &validationErr) {
+		t.Fatalf("expected nothing to redo, got %T: %v", err, err)
+	}
+
+	// undoing the add removes the task altogether
+	for range 3 {
+		if _, err := svc.Undo(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(repo.tasks) != 0 {
+		t.Errorf("expected no tasks after undoing everything, got %+v", repo.tasks)
+	}
+	if _, err := svc.Undo(); !errors.As(err, &validationErr) {
+		t.Fatalf("expected nothing to undo, got %T: %v", err, err)
+	}
+}
+
+func TestUndo_RefusesWhenTheTaskChangedSince(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: 1}}}
+	journal := &memJournal{}
+	svc := NewTaskService(collection.New(repo), WithJournal(journal, "update 1"))
+
+	if err := svc.Update(1, "B"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a change that bypasses the journal, e.g. from an older version of the tool
+	stale := NewTaskService(collection.New(repo))
+	if err := stale.Update(1, "C"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := svc.Undo()
+	var conflictErr *domain.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected ConflictError, got %T: %v", err, err)
+	}
+	if stacks, _ := svc.Journal(); len(stacks.Undo) != 1 {
+		t.Errorf("expected the entry to stay on the undo stack, got %+v", stacks)
+	}
+}
+
+func TestUndo_RefusesAnEntryOfAnotherStore(t *testing.T) {
+	journal := &memJournal{}
+	other := &memRepo{}
+	if _, err := NewTaskService(collection.New(other), WithJournal(journal, "add B")).Add("B"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// same ID and revision, but not the task the entry recorded
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: other.tasks[0].Revision}}}
+	_, err := NewTaskService(collection.New(repo), WithJournal(journal, "undo")).Undo()
+	var conflictErr *domain.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected ConflictError, got %T: %v", err, err)
+	}
+	if len(repo.tasks) != 1 {
+		t.Errorf("expected task A to be kept, got %+v", repo.tasks)
+	}
+}
+
+func TestUndo_ComparesTheWholeTask(t *testing.T) {
+	journal := &memJournal{}
+	other := &memRepo{}
+	if _, err := NewTaskService(collection.New(other), WithJournal(journal, "add B")).Add("B"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the same task but for its tags: undoing the add would delete a task it did not create
+	task := other.tasks[0].Clone()
+	task.Tags = []string{"urgent"}
+	repo := &memRepo{tasks: []domain.Task{task}}
+	_, err := NewTaskService(collection.New(repo), WithJournal(journal, "undo")).Undo()
+	var conflictErr *domain.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected ConflictError, got %T: %v", err, err)
+	}
+}
+
+func TestJournal_RecordsOnlyTheChangedFields(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Priority: domain.PriorityMedium, Revision: 1}}}
+	journal := &memJournal{}
+	store := collection.New(repo)
+	for i := 0; i < 3; i++ {
+		if err := NewTaskService(store, WithClock(fixedClock)).Comment(1, "a long discussion"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	svc := NewTaskService(store, WithClock(fixedClock), WithJournal(journal, "prioritize 1 high"))
+	if err := svc.Prioritize(1, domain.PriorityHigh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := journal.stacks.Undo[0].Changes[0]
+	if len(c.Before.Fields) != 1 || string(c.Before.Fields["priority"]) != `"medium"` || string(c.After.Fields["priority"]) != `"high"` {
+		t.Fatalf("expected only the priority to be recorded, got %v -> %v", c.Before.Fields, c.After.Fields)
+	}
+
+	if _, err := svc.Undo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task, _ := svc.Get(1)
+	if task.Priority != domain.PriorityMedium || len(task.Comments) != 3 {
+		t.Errorf("expected the priority back and the comments kept, got %+v", task)
+	}
+	if _, err := svc.Redo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task, _ = svc.Get(1); task.Priority != domain.PriorityHigh {
+		t.Errorf("expected the priority to be redone, got %s", task.Priority)
+	}
+}
+
+// brokenJournal fails every write
+type brokenJournal struct{ memJournal }
+
+func (j *brokenJournal) Update(func(stacks *ports.JournalStacks) error) error {
+	return errors.New("disk full")
+}
+
+func TestJournal_FailureRollsBackTheChange(t *testing.T) {
+	repo := &memRepo{}
+	svc := NewTaskService(collection.New(repo), WithJournal(&brokenJournal{}, "add A"))
+
+	if _, err := svc.Add("A"); err == nil {
+		t.Fatalf("expected the journal error")
+	}
+	if len(repo.tasks) != 0 {
+		t.Errorf("expected nothing saved without a journal entry, got %+v", repo.tasks)
+	}
+}
+
+// uncommittedStore runs every unit of work but fails to commit it, like a database whose disk is full
+type uncommittedStore struct{ ports.TaskStore }
+
+func (s uncommittedStore) Atomic(fn func(tx ports.TaskStore) error) error {
+	if err := s.TaskStore.Atomic(fn); err != nil {
+		return err
+	}
+	return errors.New("disk full")
+}
+
+func TestJournal_NothingJournaledWhenTheCommitFails(t *testing.T) {
+	journal := &memJournal{}
+	svc := NewTaskService(uncommittedStore{collection.New(&memRepo{})}, WithJournal(journal, "add A"))
+
+	if _, err := svc.Add("A"); err == nil {
+		t.Fatalf("expected the commit error")
+	}
+	if len(journal.stacks.Undo) != 0 {
+		t.Errorf("expected no entry for a unit of work that did not commit, got %+v", journal.stacks.Undo)
+	}
+}
+
+func TestComment(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithActor("alice"))
+
+	if err := svc.Comment(1, "Blocked on review"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.Comment{{At: "2025-03-12T09:00:00Z", Author: "alice", Text: "Blocked on review"}}
+	if !reflect.DeepEqual(repo.tasks[0].Comments, want) {
+		t.Errorf("expected %+v, got %+v", want, repo.tasks[0].Comments)
+	}
+
+	var notFound *domain.NotFoundError
+	if err := svc.Comment(2, "Hello"); !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError, got %T: %v", err, err)
+	}
+}
+
+func TestProjects(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "Inbox task", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	if _, err := svc.CreateProject("api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var validationErr *domain.ValidationError
+	if _, err := svc.CreateProject("api"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a duplicate project, got %T: %v", err, err)
+	}
+
+	epic, err := svc.AddWith("Epic", AddOptions{Project: "api"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	story, err := svc.AddWith("Story", AddOptions{ParentID: epic.ID})
+	if err != nil || story.ProjectName() != "api" {
+		t.Fatalf("expected the subtask to inherit project api, got %+v, %v", story, err)
+	}
+	if _, err := svc.AddWith("Lost", AddOptions{Project: "web"}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for an unknown project, got %T: %v", err, err)
+	}
+
+	tasks, err := svc.List(ListQuery{Project: "api"})
+	if err != nil || !reflect.DeepEqual(ids(tasks), []int{2, 3}) {
+		t.Fatalf("expected tasks 2 and 3 in project api, got %v, %v", ids(tasks), err)
+	}
+	tasks, _ = svc.List(ListQuery{Project: domain.DefaultProject})
+	if !reflect.DeepEqual(ids(tasks), []int{1}) {
+		t.Errorf("expected task 1 in the default project, got %v", ids(tasks))
+	}
+	var notFound *domain.NotFoundError
+	if _, err := svc.List(ListQuery{Project: "web"}); !errors.As(err, &notFound) {
+		t.Errorf("expected NotFoundError when listing an unknown project, got %T: %v", err, err)
+	}
+
+	summaries, err := svc.Projects()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Name != domain.DefaultProject || summaries[1].Name != "api" || summaries[1].Total != 2 {
+		t.Errorf("expected the default project then api with 2 tasks, got %+v", summaries)
+	}
+}
+
+func TestRenameProject(t *testing.T) {
+	repo := &memRepo{
+		tasks:    []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Project: "api"}},
+		projects: []domain.Project{{Name: "api", CreatedAt: "2025-01-01T00:00:00Z"}, {Name: "web"}},
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithActor("alice"))
+
+	var validationErr *domain.ValidationError
+	if err := svc.RenameProject("api", "web"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError when renaming onto an existing project, got %T: %v", err, err)
+	}
+	if err := svc.RenameProject("api", "backend"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []domain.Project{{Name: "backend", CreatedAt: "2025-01-01T00:00:00Z"}, {Name: "web"}}
+	if !reflect.DeepEqual(repo.projects, want) {
+		t.Errorf("expected %+v, got %+v", want, repo.projects)
+	}
+	task, _ := svc.Get(1)
+	last := task.History[len(task.History)-1]
+	if task.Project != "backend" || last.Field != "project" || last.Old != "api" || last.Actor != "alice" {
+		t.Errorf("expected the task to move with a recorded change, got %+v", task)
+	}
+}
+
+func TestUpdateWith_MovesATaskToAnotherProject(t *testing.T) {
+	repo := &memRepo{
+		tasks:    []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Revision: 2}},
+		projects: []domain.Project{{Name: "api"}},
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithActor("alice"))
+
+	var conflict *domain.ConflictError
+	if err := svc.UpdateWith(1, UpdateOptions{Project: "api", Revision: 1}); !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError for a stale revision, got %T: %v", err, err)
+	}
+	var validationErr *domain.ValidationError
+	if err := svc.UpdateWith(1, UpdateOptions{Project: "web"}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for an unknown project, got %T: %v", err, err)
+	}
+	if err := svc.UpdateWith(1, UpdateOptions{Project: "api", Revision: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task, _ := svc.Get(1)
+	last := task.History[len(task.History)-1]
+	if task.Project != "api" || task.Description != "A" || last.Field != "project" || last.New != "api" {
+		t.Errorf("expected the task to move to api with a recorded change, got %+v", task)
+	}
+
+	if err := svc.UpdateWith(1, UpdateOptions{Project: domain.DefaultProject}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task, _ = svc.Get(1); task.Project != "" {
+		t.Errorf("expected the task back in the default project, got %q", task.Project)
+	}
+}
+
+// failingRepo fails every Save while fail is set
+type failingRepo struct {
+	memRepo
+	fail bool
+}
+
+func (r *failingRepo) Save(t []domain.Task) error {
+	if r.fail {
+		return errors.New("disk full")
+	}
+	return r.memRepo.Save(t)
+}
+
+func TestRenameProject_ArchiveFailureRenamesNothing(t *testing.T) {
+	repo := &memRepo{
+		tasks:    []domain.Task{{ID: 1, Description: "A", Status: domain.StatusTodo, Project: "api"}},
+		projects: []domain.Project{{Name: "api"}},
+	}
+	archive := &failingRepo{memRepo: memRepo{tasks: []domain.Task{{ID: 2, Description: "B", Status: domain.StatusDone, Project: "api"}}}, fail: true}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithArchive(collection.New(archive)))
+
+	if err := svc.RenameProject("api", "backend"); err == nil {
+		t.Fatalf("expected the archive error")
+	}
+	if repo.projects[0].Name != "api" || repo.tasks[0].Project != "api" {
+		t.Fatalf("expected nothing renamed, got %+v and %+v", repo.projects, repo.tasks)
+	}
+
+	archive.fail = false
+	if err := svc.RenameProject("api", "backend"); err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if repo.tasks[0].Project != "backend" || archive.tasks[0].Project != "backend" {
+		t.Errorf("expected active and archived tasks in backend, got %+v and %+v", repo.tasks, archive.tasks)
+	}
+}
+
+func TestDeleteProject(t *testing.T) {
+	repo := &memRepo{
+		tasks: []domain.Task{
+			{ID: 1, Description: "A", Status: domain.StatusTodo, Project: "api"},
+			{ID: 2, Description: "B", Status: domain.StatusTodo},
+		},
+		projects: []domain.Project{{Name: "api"}},
+	}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	var validationErr *domain.ValidationError
+	if _, err := svc.DeleteProject("api", false); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for a project with tasks, got %T: %v", err, err)
+	}
+	if _, err := svc.DeleteProject(domain.DefaultProject, true); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for the default project, got %T: %v", err, err)
+	}
+
+	trashed, err := svc.DeleteProject("api", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids(trashed), []int{1}) || len(repo.projects) != 0 {
+		t.Fatalf("expected task 1 trashed and the project gone, got %v, %+v", ids(trashed), repo.projects)
+	}
+
+	if err := svc.Restore(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task, _ := svc.Get(1)
+	if task.ProjectName() != domain.DefaultProject {
+		t.Errorf("expected the restored task in the default project, got %q", task.ProjectName())
+	}
+}
+
+func TestSetFieldsAndListByField(t *testing.T) {
+	fields, err := domain.NewFieldSet([]domain.FieldDef{{Name: "customer"}, {Name: "estimate", Type: domain.FieldNumber}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo},
+		{ID: 2, Description: "B", Status: domain.StatusTodo},
+		{ID: 3, Description: "C", Status: domain.StatusTodo},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithActor("alice"), WithFields(fields))
+
+	for id, assignments := range map[int][]string{1: {"customer=acme", "estimate=10"}, 2: {"estimate=9"}} {
+		if err := svc.SetFields(id, assignments...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var validationErr *domain.ValidationError
+	if err := svc.SetFields(3, "customer=globex", "estimate=lots"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for an invalid number, got %T: %v", err, err)
+	}
+	if task, _ := svc.Get(3); task.Fields != nil {
+		t.Fatalf("expected no field set when one assignment fails, got %v", task.Fields)
+	}
+
+	tests := []struct {
+		filters []string
+		sort    string
+		want    []int
+	}{
+		{[]string{"customer=acme"}, "", []int{1}},
+		{[]string{"estimate>=9"}, "", []int{1, 2}},
+		{[]string{"estimate<10"}, "", []int{2}},
+		{[]string{"!customer"}, "", []int{2, 3}},
+		{[]string{"customer!=acme"}, "", []int{2, 3}},
+		{nil, "estimate", []int{2, 1, 3}},
+	}
+	for _, tt := range tests {
+		q := ListQuery{}
+		var err error
+		if q.Fields, err = ParseFieldFilter(fields, tt.filters); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if q.Sort, err = ParseSortOrder(fields, tt.sort); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tasks, err := svc.List(q)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(ids(tasks), tt.want) {
+			t.Errorf("%v sorted by %q: expected %v, got %v", tt.filters, tt.sort, tt.want, ids(tasks))
+		}
+	}
+
+	if _, err := ParseFieldFilter(fields, []string{"ticket=1"}); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for an undeclared field, got %T: %v", err, err)
+	}
+	history, _ := svc.History(2)
+	if last := history[len(history)-1]; last.Field != "field.estimate" || last.New != "9" || last.Actor != "alice" {
+		t.Errorf("expected the field change to be recorded, got %+v", last)
+	}
+}
+
+func TestChecklist_AutoDone(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "Release", Status: domain.StatusInProgress},
+		{ID: 2, Description: "Epic", Status: domain.StatusTodo},
+		{ID: 3, Description: "Story", Status: domain.StatusTodo, ParentID: 2},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithAutoDone(true))
+
+	for _, id := range []int{1, 2} {
+		for _, text := range []string{"Tag", "Publish"} {
+			if err := svc.AddCheckItem(id, text); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	if _, completed, _, err := svc.ToggleCheckItem(1, 1); err != nil || completed {
+		t.Fatalf("expected no completion with an unchecked item left, got %v, %v", completed, err)
+	}
+	item, completed, _, err := svc.ToggleCheckItem(1, 2)
+	if err != nil || !item.Done || !completed {
+		t.Fatalf("expected the last item to complete the task, got %+v, %v, %v", item, completed, err)
+	}
+	if task, _ := svc.Get(1); task.Status != domain.StatusDone {
+		t.Errorf("expected task 1 done, got %s", task.Status)
+	}
+
+	// an open subtask keeps the epic open, but the item is still checked
+	_, _, _, _ = svc.ToggleCheckItem(2, 1)
+	if _, completed, _, err := svc.ToggleCheckItem(2, 2); err != nil || completed {
+		t.Fatalf("expected the epic to stay open, got %v, %v", completed, err)
+	}
+	epic, _ := svc.Get(2)
+	if epic.Status != domain.StatusTodo || !epic.ChecklistComplete() {
+		t.Errorf("expected a checked checklist on an open epic, got %+v", epic)
+	}
+
+	if err := svc.RemoveCheckItem(2, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if epic, _ := svc.Get(2); len(epic.Checklist) != 1 || epic.Checklist[0].Text != "Publish" {
+		t.Errorf("expected only Publish left, got %+v", epic.Checklist)
+	}
+}
+
+func TestChecklist_AutoDoneRecurs(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{
+		ID: 1, Description: "Rotate keys", Status: domain.StatusTodo, Recurrence: "weekly", Due: "2025-03-12",
+		Checklist: []domain.CheckItem{{Text: "Revoke old keys"}},
+	}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithAutoDone(true))
+
+	_, completed, next, err := svc.ToggleCheckItem(1, 1)
+	if err != nil || !completed {
+		t.Fatalf("expected the task completed, got %v, %v", completed, err)
+	}
+	if next == nil || next.ID != 2 || next.Due != "2025-03-19" {
+		t.Fatalf("expected the next occurrence returned, got %+v", next)
+	}
+	if task, err := svc.Get(2); err != nil || len(task.Checklist) != 1 || task.Checklist[0].Done {
+		t.Errorf("expected the next occurrence stored with an unchecked checklist, got %+v, %v", task, err)
+	}
+}
+
+func TestChecklist_WithoutAutoDone(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{{ID: 1, Description: "Release", Status: domain.StatusTodo}}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	_ = svc.AddCheckItem(1, "Tag")
+	if _, completed, _, err := svc.ToggleCheckItem(1, 1); err != nil || completed {
+		t.Fatalf("expected no completion without auto-done, got %v, %v", completed, err)
+	}
+	if task, _ := svc.Get(1); task.Status != domain.StatusTodo {
+		t.Errorf("expected task 1 still todo, got %s", task.Status)
+	}
+}
+
+func TestVelocity(t *testing.T) {
+	doneAt := func(at string) []domain.Change {
+		return []domain.Change{{At: at, Field: "status", Old: "todo", New: "done"}}
+	}
+	created := "2025-03-01T09:00:00Z"
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusDone, CreatedAt: created, Estimate: "3", History: doneAt("2025-03-04T10:00:00Z")},
+		{ID: 2, Description: "B", Status: domain.StatusDone, CreatedAt: created, Estimate: "5", History: doneAt("2025-03-11T10:00:00Z")},
+		{ID: 3, Description: "C", Status: domain.StatusDone, CreatedAt: created, Estimate: "2h", History: doneAt("2025-03-11T11:00:00Z")},
+		{ID: 4, Description: "D", Status: domain.StatusTodo, CreatedAt: created, Estimate: "8"},
+		{ID: 5, Description: "E", Status: domain.StatusTodo, CreatedAt: created},
+		{ID: 6, Description: "F", Status: domain.StatusDone, CreatedAt: created, Estimate: "13", History: doneAt("2025-02-20T10:00:00Z")},
+		{ID: 7, Description: "G", Status: domain.StatusCancelled, CreatedAt: created, Estimate: "21"},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	v, err := svc.Velocity(VelocityQuery{Weeks: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(v.Weeks) != 2 || v.Weeks[0].Start.Format(domain.DateLayout) != "2025-03-03" {
+		t.Fatalf("expected the weeks of March 3 and 10, got %+v", v.Weeks)
+	}
+	if v.Weeks[0].Done.Points != 3 || v.Weeks[1].Done.Points != 5 || v.Weeks[1].Done.Duration != 2*time.Hour || v.Weeks[1].Tasks != 2 {
+		t.Errorf("unexpected weekly totals %+v", v.Weeks)
+	}
+	if v.Average.Points != 4 || v.Remaining.Points != 8 || v.Unestimated != 1 {
+		t.Errorf("expected 4 points a week, 8 remaining and 1 unestimated task, got %+v", v)
+	}
+	if got := v.PointsDone.Format(domain.DateLayout); got != "2025-03-26" {
+		t.Errorf("expected the points finished in two weeks, on 2025-03-26, got %s", got)
+	}
+	if !v.HoursDone.IsZero() {
+		t.Errorf("expected no forecast without remaining hours, got %s", v.HoursDone)
+	}
+}
+
+func TestVelocity_WeeksAreBounded(t *testing.T) {
+	svc := NewTaskService(collection.New(&memRepo{}), WithClock(fixedClock))
+
+	var validationErr *domain.ValidationError
+	for _, weeks := range []int{0, maxVelocityWeeks + 1, 100000000} {
+		if _, err := svc.Velocity(VelocityQuery{Weeks: weeks}); !errors.As(err, &validationErr) {
+			t.Errorf("weeks %d: expected ValidationError, got %T: %v", weeks, err, err)
+		}
+	}
+	if v, err := svc.Velocity(VelocityQuery{Weeks: maxVelocityWeeks}); err != nil || len(v.Weeks) != maxVelocityWeeks {
+		t.Errorf("expected %d weeks, got %d, %v", maxVelocityWeeks, len(v.Weeks), err)
+	}
+}
+
+func TestVelocity_StartsAtTheFirstTask(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo, CreatedAt: "2025-03-11T09:00:00Z", Estimate: "3"},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock))
+
+	v, err := svc.Velocity(VelocityQuery{Weeks: 8})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.Weeks) != 1 || !v.PointsDone.IsZero() {
+		t.Errorf("expected a single week without forecast, got %+v", v)
+	}
+}
+
+func TestAssignAndListByAssignee(t *testing.T) {
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo},
+		{ID: 2, Description: "B", Status: domain.StatusTodo},
+		{ID: 3, Description: "C", Status: domain.StatusTodo},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithActor("carol"))
+
+	if err := svc.Assign(1, "alice", "bob"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Assign(2, "bob"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Unassign(1, "bob"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task, _ := svc.Get(1)
+	if want := []string{"alice"}; !reflect.DeepEqual(task.Assignees, want) {
+		t.Errorf("expected %v, got %v", want, task.Assignees)
+	}
+	last := task.History[len(task.History)-1]
+	if want := (domain.Change{At: "2025-03-12T09:00:00Z", Actor: "carol", Field: "assignees", Old: "alice bob", New: "alice"}); last != want {
+		t.Errorf("expected %+v, got %+v", want, last)
+	}
+
+	for assignee, want := range map[string][]int{"alice": {1}, "bob": {2}, domain.NoAssignee: {3}, "dave": {}} {
+		tasks, err := svc.List(ListQuery{Assignee: assignee})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := ids(tasks); len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("assigned to %s: expected %v, got %v", assignee, want, got)
+		}
+	}
+
+	var validationErr *domain.ValidationError
+	if _, err := svc.List(ListQuery{Assignee: "two words"}); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for an invalid assignee, got %T: %v", err, err)
+	}
+	if err := svc.Assign(3, domain.NoAssignee); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for the reserved name, got %T: %v", err, err)
+	}
+}
+
+func TestVelocity_CountsArchivedTasks(t *testing.T) {
+	done := []domain.Change{{At: "2025-03-11T10:00:00Z", Field: "status", Old: "todo", New: "done"}}
+	created := "2025-03-01T09:00:00Z"
+	repo := &memRepo{tasks: []domain.Task{
+		{ID: 1, Description: "A", Status: domain.StatusTodo, CreatedAt: created, Estimate: "1000000"},
+	}}
+	archive := &memRepo{tasks: []domain.Task{
+		{ID: 2, Description: "B", Status: domain.StatusDone, CreatedAt: created, Estimate: "0.0001", History: done},
+	}}
+	svc := NewTaskService(collection.New(repo), WithClock(fixedClock), WithArchive(collection.New(archive)))
+
+	v, err := svc.Velocity(VelocityQuery{Weeks: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.Weeks) != 1 || v.Weeks[0].Tasks != 1 || v.Weeks[0].Done.Points != 0.0001 {
+		t.Errorf("expected the archived task to count, got %+v", v.Weeks)
+	}
+	if !v.PointsDone.IsZero() {
+		t.Errorf("expected no forecast beyond a century, got %s", v.PointsDone)
+	}
+}
+
+func TestParseFieldFilter_SplitsAtTheFirstOperator(t *testing.T) {
+	fields, err := domain.NewFieldSet([]domain.FieldDef{{Name: "note"}, {Name: "estimate", Type: domain.FieldNumber}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		expr, name, op, value string
+	}{
+		{"note=a!=b", "note", "=", "a!=b"},
+		{"note!=a=b", "note", "!=", "a=b"},
+		{"estimate<=3", "estimate", "<=", "3"},
+		{"estimate>=3", "estimate", ">=", "3"},
+		{"note", "note", "set", ""},
+		{"!note", "note", "unset", ""},
+	}
+	for _, tt := range tests {
+		f, err := ParseFieldFilter(fields, []string{tt.expr})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.expr, err)
+		}
+		if c := f[0]; c.def.Name != tt.name || c.op != tt.op || c.value != tt.value {
+			t.Errorf("%q: expected %s %s %q, got %+v", tt.expr, tt.name, tt.op, tt.value, c)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package application
+
+import (
+	"fmt"
+	"sort"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+)
+
+// StartTimer starts timing work on task id.
+// Only one timer may run at a time across all tasks; stop the running one first.
+func (s *TaskService) StartTimer(id int) error {
+	return s.withTaskTx(id, func(tx ports.TaskStore, t *domain.Task) error {
+		running, err := runningTask(tx)
+		if err != nil {
+			return err
+		}
+		if running != nil {
+			return &domain.ValidationError{Msg: fmt.Sprintf("a timer is already running on task %d; stop it first", running.ID)}
+		}
+
+		return t.StartTimer(s.workflow, s.now())
+	})
+}
+
+// StopTimer stops the running timer and returns the task it ran on and for how long.
+func (s *TaskService) StopTimer() (domain.Task, time.Duration, error) {
+	var (
+		task    domain.Task
+		elapsed time.Duration
+	)
+	err := s.store.Atomic(func(tx ports.TaskStore) error {
+		running, err := runningTask(tx)
+		if err != nil {
+			return err
+		}
+		if running == nil {
+			return &domain.ValidationError{Msg: "no timer is running"}
+		}
+
+		task = *running
+		before := task.Clone()
+		if elapsed, err = task.StopTimer(s.now()); err != nil {
+			return err
+		}
+		if err := s.update(tx, before, &task); err != nil {
+			return err
+		}
+
+		task, err = tx.Get(task.ID) // as persisted, with the revision the store gave it
+		return err
+	})
+
+	return task, elapsed, err
+}
+
+// LogTime records d of work on task id that ended now.
+func (s *TaskService) LogTime(id int, d time.Duration) error {
+	return s.withTask(id, func(t *domain.Task) error {
+		return t.LogTime(d, s.now())
+	})
+}
+
+// runningTask returns the task with an active timer, or nil if no timer is running.
+func runningTask(tx ports.TaskStore) (*domain.Task, error) {
+	tasks, err := tx.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		if _, ok := t.ActiveTimer(); ok {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// Timesheet is the work logged during one week (Monday to Sunday), per task and per day.
+type Timesheet struct {
+	Days      [7]time.Time // midnight of each day, Monday first
+	Rows      []TimesheetRow
+	DayTotals [7]time.Duration
+	Total     time.Duration
+}
+
+// TimesheetRow is the time spent on one task during the week.
+type TimesheetRow struct {
+	Task  domain.Task
+	Days  [7]time.Duration
+	Total time.Duration
+}
+
+// Timesheet sums the time entries of the week containing day, in the location of day.
+// Entries are attributed to the day they started on; a running timer counts up to now.
+// Tasks are ordered by time spent, most first.
+func (s *TaskService) Timesheet(day time.Time) (Timesheet, error) {
+	tasks, err := s.store.List()
+	if err != nil {
+		return Timesheet{}, err
+	}
+
+	var sheet Timesheet
+	monday := domain.WeekStart(day)
+	for i := range sheet.Days {
+		sheet.Days[i] = monday.AddDate(0, 0, i)
+	}
+	end := monday.AddDate(0, 0, 7)
+
+	now := s.now()
+	for _, t := range tasks {
+		row := TimesheetRow{Task: t}
+		for _, e := range t.TimeEntries {
+			start, stop, err := e.Span(now)
+			if err != nil {
+				return Timesheet{}, err
+			}
+
+			start = start.In(day.Location())
+			if start.Before(monday) || !start.Before(end) {
+				continue
+			}
+
+			i := daysBetween(monday, start)
+			row.Days[i] += stop.Sub(start)
+			row.Total += stop.Sub(start)
+		}
+
+		if row.Total == 0 {
+			continue
+		}
+		for i, d := range row.Days {
+			sheet.DayTotals[i] += d
+		}
+		sheet.Total += row.Total
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	sort.SliceStable(sheet.Rows, func(i, j int) bool {
+		if sheet.Rows[i].Total != sheet.Rows[j].Total {
+			return sheet.Rows[i].Total > sheet.Rows[j].Total
+		}
+		return sheet.Rows[i].Task.ID < sheet.Rows[j].Task.ID
+	})
+
+	return sheet, nil
+}
+
+// daysBetween counts the calendar days from the date of a to the date of b, both read in their own location.
+// Unlike dividing the elapsed hours, it is not thrown off by days that DST makes 23 or 25 hours long.
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
@@ -0,0 +1,167 @@
+package application
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+	"time"
+)
+
+// liveStore hides trashed tasks, so that every use-case except the trash ones sees only live tasks.
+// A trashed task reads as not found; references to it (parent, blockers) are kept for a restore.
+type liveStore struct {
+	ports.TaskStore
+}
+
+func (s liveStore) Get(id int) (domain.Task, error) {
+	t, err := s.TaskStore.Get(id)
+	if err == nil && t.IsTrashed() {
+		return domain.Task{}, &domain.NotFoundError{Msg: fmt.Sprintf("task %d is in the trash (restore it first)", id)}
+	}
+	return t, err
+}
+
+func (s liveStore) List() ([]domain.Task, error) {
+	return live(s.TaskStore.List())
+}
+
+func (s liveStore) ListByStatus(status domain.TaskStatus) ([]domain.Task, error) {
+	return live(s.TaskStore.ListByStatus(status))
+}
+
+func (s liveStore) Atomic(fn func(tx ports.TaskStore) error) error {
+	return s.TaskStore.Atomic(func(tx ports.TaskStore) error {
+		return fn(liveStore{tx})
+	})
+}
+
+func live(tasks []domain.Task, err error) ([]domain.Task, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	res := tasks[:0:0]
+	for _, t := range tasks {
+		if !t.IsTrashed() {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+// Trash lists the trashed tasks, most recently deleted first.
+func (s *TaskService) Trash() ([]domain.Task, error) {
+	tasks, err := s.all.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var trashed []domain.Task
+	for _, t := range tasks {
+		if t.IsTrashed() {
+			trashed = append(trashed, t)
+		}
+	}
+
+	sort.SliceStable(trashed, func(i, j int) bool {
+		if trashed[i].DeletedAt != trashed[j].DeletedAt {
+			return trashed[i].DeletedAt > trashed[j].DeletedAt
+		}
+		return trashed[i].ID < trashed[j].ID
+	})
+	return trashed, nil
+}
+
+// Restore takes a task out of the trash. A subtask can only come back once its parent has,
+// and a task whose project was deleted meanwhile comes back in the default project.
+func (s *TaskService) Restore(id int) error {
+	return s.all.Atomic(func(tx ports.TaskStore) error {
+		task, err := tx.Get(id)
+		if err != nil {
+			return err
+		}
+
+		if task.ParentID != 0 {
+			parent, err := tx.Get(task.ParentID)
+			if err != nil {
+				return parentError(task.ParentID, err)
+			}
+			if parent.IsTrashed() {
+				return &domain.ValidationError{Msg: fmt.Sprintf("parent task %d is in the trash; restore it first", parent.ID)}
+			}
+		}
+
+		before := task.Clone()
+		if err := task.Restore(); err != nil {
+			return err
+		}
+		if err := rehome(tx, &task); err != nil {
+			return err
+		}
+		return s.update(tx, before, &task)
+	})
+}
+
+// Purge permanently deletes the tasks that went to the trash more than olderThan ago and returns them.
+// Remaining tasks stop referring to them as parent or blocker. A purge cannot be undone.
+func (s *TaskService) Purge(olderThan time.Duration) ([]domain.Task, error) {
+	cutoff := s.now().Add(-olderThan)
+	if olderThan == 0 {
+		cutoff = s.now().Add(time.Second) // everything, even what was trashed this second
+	}
+
+	var purged []domain.Task
+	err := s.raw.Atomic(func(tx ports.TaskStore) error {
+		tasks, err := tx.List()
+		if err != nil {
+			return err
+		}
+
+		gone := map[int]bool{}
+		for _, t := range tasks {
+			old, err := t.TrashedBefore(cutoff)
+			if err != nil {
+				return err
+			}
+			if !old {
+				continue
+			}
+			if err := tx.Delete(t.ID); err != nil {
+				return err
+			}
+			gone[t.ID] = true
+			purged = append(purged, t)
+		}
+
+		for _, t := range tasks {
+			if gone[t.ID] {
+				continue
+			}
+
+			before := t.Clone()
+			for _, b := range before.BlockedBy {
+				if gone[b] {
+					t.RemoveBlocker(b)
+				}
+			}
+			if gone[t.ParentID] {
+				if err := t.SetParent(0); err != nil {
+					return err
+				}
+			}
+			if t.ParentID != before.ParentID || !slices.Equal(t.BlockedBy, before.BlockedBy) {
+				if err := s.update(tx, before, &t); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+)
+
+// TreeNode is one line of the task hierarchy in display order.
+// Done and Total count the subtasks below the task at any depth.
+type TreeNode struct {
+	Task  domain.Task
+	Depth int
+	Done  int
+	Total int
+}
+
+// Percent is the rolled-up completion of the node's subtasks, or -1 for a leaf.
+func (n TreeNode) Percent() int {
+	if n.Total == 0 {
+		return -1
+	}
+	return n.Done * 100 / n.Total
+}
+
+// Tree returns the tasks matching q as a depth-first hierarchy.
+// A matching task whose parent was filtered out is shown at the top level;
+// completion always accounts for every subtask, filtered or not.
+// The matching tasks and the whole collection are read in one unit of work, so that both reflect the same state.
+func (s *TaskService) Tree(q ListQuery) ([]TreeNode, error) {
+	var all, tasks []domain.Task
+	err := s.store.Atomic(func(tx ports.TaskStore) error {
+		if err := checkQuery(tx, q); err != nil {
+			return err
+		}
+
+		var err error
+		if all, err = tx.List(); err != nil {
+			return err
+		}
+		tasks, err = s.listFrom(tx, q)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		included[t.ID] = true
+	}
+
+	// tasks is already sorted, so appending keeps siblings in query order
+	var roots []domain.Task
+	children := map[int][]domain.Task{}
+	for _, t := range tasks {
+		if t.ParentID != 0 && included[t.ParentID] {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	progress := domain.SubtaskProgress(s.workflow, all)
+
+	nodes := make([]TreeNode, 0, len(tasks))
+	var visit func(t domain.Task, depth int)
+	visit = func(t domain.Task, depth int) {
+		p := progress[t.ID]
+		nodes = append(nodes, TreeNode{Task: t, Depth: depth, Done: p.Done, Total: p.Total})
+		for _, c := range children[t.ID] {
+			visit(c, depth+1)
+		}
+	}
+	for _, r := range roots {
+		visit(r, 0)
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,143 @@
+package application
+
+import (
+	"fmt"
+	"math"
+	"taskcli/internal/domain"
+	"time"
+)
+
+// VelocityQuery selects the weeks and tasks a velocity report covers.
+type VelocityQuery struct {
+	Weeks   int    // most recent weeks to average over, the current one included; 1 to maxVelocityWeeks
+	Project string // only tasks of this project; "" for all
+}
+
+// Velocity is the estimate completed per week and a naive forecast of when the open work is finished.
+type Velocity struct {
+	Weeks       []VelocityWeek  // oldest first; never before the week the first task was created
+	Average     domain.Estimate // completed per week over Weeks
+	Remaining   domain.Estimate // of the open tasks
+	Unestimated int             // open tasks without an estimate, left out of Remaining
+	PointsDone  time.Time       // when the remaining points are finished at the average pace; zero without a forecast
+	HoursDone   time.Time       // likewise for the remaining durations
+}
+
+// maxVelocityWeeks bounds the report to ten years, a week per line
+const maxVelocityWeeks = 520
+
+// maxForecast bounds forecasts, so that a tiny average pace does not overflow time.Duration
+const maxForecast = 100 * 365 * 24 * time.Hour
+
+// VelocityWeek is the work completed during one week (Monday to Sunday).
+type VelocityWeek struct {
+	Start time.Time // midnight of the Monday
+	Done  domain.Estimate
+	Tasks int // completed tasks with an estimate
+}
+
+// Velocity sums the estimates of the tasks completed during each week, by the time they were marked done,
+// and extrapolates the average pace over the remaining open estimate.
+// Archived tasks count as well, when an archive is configured.
+func (s *TaskService) Velocity(q VelocityQuery) (Velocity, error) {
+	if q.Weeks < 1 {
+		return Velocity{}, &domain.ValidationError{Msg: "velocity needs at least one week"}
+	}
+	if q.Weeks > maxVelocityWeeks {
+		return Velocity{}, &domain.ValidationError{Msg: fmt.Sprintf("velocity covers at most %d weeks", maxVelocityWeeks)}
+	}
+	tasks, err := s.List(ListQuery{Project: q.Project})
+	if err != nil {
+		return Velocity{}, err
+	}
+	if s.archive != nil {
+		archived, err := s.listFrom(s.archive, ListQuery{Project: q.Project})
+		if err != nil {
+			return Velocity{}, err
+		}
+		tasks = appendArchived(tasks, archived)
+	}
+
+	now := s.now()
+	first := domain.WeekStart(now).AddDate(0, 0, -7*(q.Weeks-1))
+	if created, ok := firstCreated(tasks); ok && created.After(first) {
+		first = domain.WeekStart(created.In(now.Location()))
+	}
+
+	var v Velocity
+	index := map[int64]int{} // by the Unix time of the Monday
+	for w := first; !w.After(now); w = w.AddDate(0, 0, 7) {
+		index[w.Unix()] = len(v.Weeks)
+		v.Weeks = append(v.Weeks, VelocityWeek{Start: w})
+	}
+
+	for _, t := range tasks {
+		est := t.EstimateValue()
+		switch {
+		case !s.workflow.IsClosed(t.Status) && est.IsZero():
+			v.Unestimated++
+		case !s.workflow.IsClosed(t.Status):
+			v.Remaining = v.Remaining.Add(est)
+		case s.workflow.IsDone(t.Status) && !est.IsZero():
+			done, err := t.ClosedAt(s.workflow)
+			if err != nil {
+				return Velocity{}, err
+			}
+			if i, ok := index[domain.WeekStart(done.In(now.Location())).Unix()]; ok {
+				v.Weeks[i].Done = v.Weeks[i].Done.Add(est)
+				v.Weeks[i].Tasks++
+			}
+		}
+	}
+
+	var total domain.Estimate
+	for _, w := range v.Weeks {
+		total = total.Add(w.Done)
+	}
+	n := float64(len(v.Weeks))
+	v.Average = domain.Estimate{Points: total.Points / n, Duration: time.Duration(float64(total.Duration) / n)}
+
+	if v.Remaining.Points > 0 && v.Average.Points > 0 {
+		v.PointsDone = forecast(now, v.Remaining.Points/v.Average.Points)
+	}
+	if v.Remaining.Duration > 0 && v.Average.Duration > 0 {
+		v.HoursDone = forecast(now, float64(v.Remaining.Duration)/float64(v.Average.Duration))
+	}
+	return v, nil
+}
+
+// appendArchived adds the archived tasks that are not also active;
+// an interrupted archive run may leave a task in both stores.
+func appendArchived(active, archived []domain.Task) []domain.Task {
+	seen := make(map[int]bool, len(active))
+	for _, t := range active {
+		seen[t.ID] = true
+	}
+	for _, t := range archived {
+		if !seen[t.ID] {
+			active = append(active, t)
+		}
+	}
+	return active
+}
+
+func firstCreated(tasks []domain.Task) (time.Time, bool) {
+	var first time.Time
+	for _, t := range tasks {
+		created, err := time.Parse(time.RFC3339, t.CreatedAt)
+		if err == nil && (first.IsZero() || created.Before(first)) {
+			first = created
+		}
+	}
+	return first, !first.IsZero()
+}
+
+// forecast is the day the given number of weeks from now falls on; zero when it is further than maxForecast.
+func forecast(now time.Time, weeks float64) time.Time {
+	ahead := weeks * 7 * 24 * float64(time.Hour)
+	if math.IsNaN(ahead) || ahead > float64(maxForecast) {
+		return time.Time{}
+	}
+	end := now.Add(time.Duration(ahead))
+	return time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())
+}
@@ -0,0 +1,55 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"taskcli/internal/domain"
+	"taskcli/internal/ports"
+)
+
+// WithWorkflow replaces the built-in statuses and transitions, e.g. with one built by domain.NewWorkflow.
+func WithWorkflow(w domain.Workflow) Option {
+	return func(s *TaskService) { s.workflow = w }
+}
+
+// Workflow returns the statuses and transitions the service works with.
+func (s *TaskService) Workflow() domain.Workflow {
+	return s.workflow
+}
+
+// declaredStore refuses to hand out a task whose status the workflow does not declare,
+// e.g. after a status was removed from the settings, instead of guessing what the status means.
+// Listings still include such tasks, so that one of them does not break every listing;
+// the workflow counts them as neither active nor closed. Move reads past it so that they can be moved on.
+type declaredStore struct {
+	ports.TaskStore
+	svc *TaskService
+}
+
+func (s declaredStore) Get(id int) (domain.Task, error) {
+	t, err := s.TaskStore.Get(id)
+	if err != nil {
+		return t, err
+	}
+	return t, s.check(t)
+}
+
+func (s declaredStore) Atomic(fn func(tx ports.TaskStore) error) error {
+	return s.TaskStore.Atomic(func(tx ports.TaskStore) error {
+		return fn(declaredStore{TaskStore: tx, svc: s.svc})
+	})
+}
+
+func (s declaredStore) check(t domain.Task) error {
+	w := s.svc.workflow
+	if w.Declares(t.Status) {
+		return nil
+	}
+
+	names := make([]string, len(w.Statuses))
+	for i, st := range w.Statuses {
+		names[i] = string(st.Name)
+	}
+	return &domain.ValidationError{Msg: fmt.Sprintf("task %d has status %q, which the workflow does not declare (statuses: %s); move it to one of them or declare the status again",
+		t.ID, t.Status, strings.Join(names, "|"))}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"taskcli/internal/domain"
+)
+
+// Config holds user settings read from a JSON file.
+// Every field is optional; the zero value is the built-in behaviour.
+type Config struct {
+	User     string    `json:"user,omitempty"`     // who is recorded in task history and whose tasks list --mine shows; defaults to $USER
+	Project  string    `json:"project,omitempty"`  // given to new tasks without --project; defaults to the inbox
+	Workflow *Workflow `json:"workflow,omitempty"` // replaces the built-in statuses and transitions
+	Fields   []Field   `json:"fields,omitempty"`   // custom fields tasks may carry
+	AutoDone bool      `json:"autoDone,omitempty"` // complete a task once its whole checklist is checked
+}
+
+// Field declares a custom field; its type (string|number|date|bool) defaults to string
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Workflow declares custom statuses and the transitions between them.
+// The first status is given to new tasks; without transitions any status can be reached from any other.
+type Workflow struct {
+	Statuses    []Status     `json:"statuses"`
+	Transitions []Transition `json:"transitions,omitempty"`
+}
+
+// Status is one workflow status; its category (open|active|done|cancelled) defaults to open
+type Status struct {
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+}
+
+// Transition is one allowed status change; a named transition also becomes a command
+type Transition struct {
+	Name    string   `json:"name,omitempty"`
+	From    []string `json:"from"`
+	To      string   `json:"to"`
+	Message string   `json:"message,omitempty"`
+}
+
+// FileName is looked up in the working directory before the user config directory
+const FileName = ".taskcli.json"
+
+// Locate returns the config file to use: explicit if set, otherwise ./.taskcli.json,
+// otherwise <user config dir>/taskcli/config.json. It returns "" when none of the defaults exist.
+func Locate(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+
+	candidates := []string{FileName}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, "taskcli", "config.json"))
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+
+	return ""
+}
+
+// Load reads the config file at path; an empty path yields the defaults.
+// Unknown keys are rejected so that typos do not go unnoticed.
+func Load(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return cfg, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// CurrentUser returns TASKCLI_USER, then the configured user, then the login name from the environment.
+// The variable comes first so that everyone sharing a settings file with a task store can still be told apart.
+// It fails with *domain.ValidationError when none of them is set.
+func (c Config) CurrentUser() (string, error) {
+	for _, name := range []string{os.Getenv("TASKCLI_USER"), c.User, os.Getenv("USER"), os.Getenv("USERNAME")} {
+		if name != "" {
+			return name, nil
+		}
+	}
+	return "", &domain.ValidationError{Msg: `no current user (set "user" in the settings or TASKCLI_USER)`}
+}
+
+// Actor returns the current user for the task history, or "unknown" when there is none.
+func (c Config) Actor() string {
+	if name, err := c.CurrentUser(); err == nil {
+		return name
+	}
+	return "unknown"
+}
+
+// DomainWorkflow converts the configured workflow; ok is false when the built-in one applies.
+func (c Config) DomainWorkflow() (w domain.Workflow, ok bool) {
+	if c.Workflow == nil {
+		return w, false
+	}
+
+	for _, st := range c.Workflow.Statuses {
+		w.Statuses = append(w.Statuses, domain.StatusDef{
+			Name:     domain.TaskStatus(st.Name),
+			Category: domain.StatusCategory(st.Category),
+		})
+	}
+	for _, tr := range c.Workflow.Transitions {
+		from := make([]domain.TaskStatus, len(tr.From))
+		for i, f := range tr.From {
+			from[i] = domain.TaskStatus(f)
+		}
+		w.Transitions = append(w.Transitions, domain.Transition{
+			Name:    tr.Name,
+			From:    from,
+			To:      domain.TaskStatus(tr.To),
+			Message: tr.Message,
+		})
+	}
+
+	return w, true
+}
+
+// DomainFields converts the configured custom fields
+func (c Config) DomainFields() []domain.FieldDef {
+	defs := make([]domain.FieldDef, len(c.Fields))
+	for i, f := range c.Fields {
+		defs[i] = domain.FieldDef{Name: f.Name, Type: domain.FieldType(f.Type)}
+	}
+	return defs
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"taskcli/internal/domain"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("no file means defaults", func(t *testing.T) {
+		cfg, err := Load("")
+		if _, custom := cfg.DomainWorkflow(); err != nil || !reflect.DeepEqual(cfg, Config{}) || custom {
+			t.Fatalf("expected defaults, got %+v, %v", cfg, err)
+		}
+	})
+
+	t.Run("user", func(t *testing.T) {
+		t.Setenv("TASKCLI_USER", "")
+		cfg, err := Load(write("ok.json", `{"user": "alice"}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Actor() != "alice" {
+			t.Errorf("expected actor alice, got %q", cfg.Actor())
+		}
+	})
+
+	t.Run("workflow", func(t *testing.T) {
+		cfg, err := Load(write("workflow.json", `{"workflow": {
+			"statuses": [{"name": "backlog"}, {"name": "shipped", "category": "done"}],
+			"transitions": [{"name": "ship", "from": ["backlog"], "to": "shipped", "message": "Shipped"}]
+		}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		w, ok := cfg.DomainWorkflow()
+		if !ok {
+			t.Fatalf("expected a custom workflow")
+		}
+		if len(w.Statuses) != 2 || w.Statuses[1] != (domain.StatusDef{Name: "shipped", Category: domain.CategoryDone}) {
+			t.Errorf("unexpected statuses %+v", w.Statuses)
+		}
+		if len(w.Transitions) != 1 || w.Transitions[0].Name != "ship" || w.Transitions[0].From[0] != "backlog" || w.Transitions[0].To != "shipped" {
+			t.Errorf("unexpected transitions %+v", w.Transitions)
+		}
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		cfg, err := Load(write("fields.json", `{"fields": [{"name": "ticket"}, {"name": "estimate", "type": "number"}]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []domain.FieldDef{{Name: "ticket"}, {Name: "estimate", Type: domain.FieldNumber}}
+		if got := cfg.DomainFields(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		if _, err := Load(write("typo.json", `{"usr": "alice"}`)); err == nil {
+			t.Fatalf("expected error for an unknown key")
+		}
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+			t.Fatalf("expected error for a missing file")
+		}
+	})
+}
+
+func TestActor_FallsBackToEnvironment(t *testing.T) {
+	t.Setenv("TASKCLI_USER", "")
+	t.Setenv("USER", "bob")
+
+	if got := (Config{}).Actor(); got != "bob" {
+		t.Errorf("expected $USER, got %q", got)
+	}
+}
+
+func TestActor_VariableOverridesSharedSettings(t *testing.T) {
+	t.Setenv("TASKCLI_USER", "carol")
+
+	if got := (Config{User: "alice"}).Actor(); got != "carol" {
+		t.Errorf("expected $TASKCLI_USER, got %q", got)
+	}
+}
+
+func TestCurrentUser_NoneResolved(t *testing.T) {
+	for _, key := range []string{"TASKCLI_USER", "USER", "USERNAME"} {
+		t.Setenv(key, "")
+	}
+
+	_, err := (Config{}).CurrentUser()
+	var validationErr *domain.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	if got := (Config{}).Actor(); got != "unknown" {
+		t.Errorf("expected the history to fall back to unknown, got %q", got)
+	}
+}
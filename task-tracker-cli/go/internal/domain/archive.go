@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClosedAt returns when the task reached its current closed status, taken from its history;
+// tasks recorded before the history existed fall back to their last update.
+func (t *Task) ClosedAt(w Workflow) (time.Time, error) {
+	if !w.IsClosed(t.Status) {
+		return time.Time{}, &ValidationError{Msg: fmt.Sprintf("task %d is %s, not closed", t.ID, t.Status)}
+	}
+
+	at := t.UpdatedAt
+	for i := len(t.History) - 1; i >= 0; i-- {
+		if c := t.History[i]; c.Field == "status" && c.New == string(t.Status) {
+			at = c.At
+			break
+		}
+	}
+
+	closed, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, &ValidationError{Msg: fmt.Sprintf("invalid close time %q of task %d", at, t.ID)}
+	}
+	return closed, nil
+}
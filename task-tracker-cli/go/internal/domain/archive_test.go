@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClosedAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    Task
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "last status change",
+			task: Task{ID: 1, Status: StatusDone, UpdatedAt: "2025-03-01T00:00:00Z", History: []Change{
+				{At: "2025-01-01T10:00:00Z", Field: "status", Old: "todo", New: "done"},
+				{At: "2025-01-05T10:00:00Z", Field: "status", Old: "done", New: "todo"},
+				{At: "2025-02-01T10:00:00Z", Field: "status", Old: "todo", New: "done"},
+				{At: "2025-02-03T10:00:00Z", Field: "tags", New: "later"},
+			}},
+			want: time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "no history",
+			task: Task{ID: 2, Status: StatusCancelled, UpdatedAt: "2025-03-01T00:00:00Z"},
+			want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "open task",
+			task:    Task{ID: 3, Status: StatusTodo, UpdatedAt: "2025-03-01T00:00:00Z"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.task.ClosedAt(DefaultWorkflow())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v, %v", tt.want, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxAssigneeLength is generous enough for e-mail addresses
+const maxAssigneeLength = 64
+
+// NoAssignee stands for "nobody" where a user name is expected, e.g. to list unassigned tasks
+const NoAssignee = "none"
+
+// ParseAssignee trims a user name and checks that it is a single word,
+// such as a login name or an e-mail address. Names are case-sensitive.
+func ParseAssignee(s string) (string, error) {
+	user := strings.TrimSpace(s)
+
+	if user == "" {
+		return "", &ValidationError{Msg: "assignee cannot be empty"}
+	}
+	if len(user) > maxAssigneeLength {
+		return "", &ValidationError{Msg: fmt.Sprintf("assignee %q is longer than %d characters", user, maxAssigneeLength)}
+	}
+	if user == NoAssignee {
+		return "", &ValidationError{Msg: fmt.Sprintf("%q is reserved and cannot be an assignee", NoAssignee)}
+	}
+	if strings.IndexFunc(user, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) >= 0 {
+		return "", &ValidationError{Msg: fmt.Sprintf("invalid assignee %q (no spaces or commas)", s)}
+	}
+
+	return user, nil
+}
+
+// IsAssignedTo reports whether user is one of the task's assignees
+func (t *Task) IsAssignedTo(user string) bool {
+	i := sort.SearchStrings(t.Assignees, user)
+	return i < len(t.Assignees) && t.Assignees[i] == user
+}
+
+// Assign adds users to the assignees, keeping them unique and sorted.
+// Nothing changes if any name is invalid.
+func (t *Task) Assign(users ...string) error {
+	assignees := append([]string(nil), t.Assignees...)
+	for _, raw := range users {
+		user, err := ParseAssignee(raw)
+		if err != nil {
+			return err
+		}
+		if i := sort.SearchStrings(assignees, user); i == len(assignees) || assignees[i] != user {
+			assignees = append(assignees[:i], append([]string{user}, assignees[i:]...)...)
+		}
+	}
+
+	return t.setAssignees(assignees)
+}
+
+// Unassign removes users from the assignees; without users, everyone is removed.
+// Users the task is not assigned to are ignored.
+func (t *Task) Unassign(users ...string) error {
+	var assignees []string
+	if len(users) > 0 {
+		remove := make(map[string]bool, len(users))
+		for _, raw := range users {
+			user, err := ParseAssignee(raw)
+			if err != nil {
+				return err
+			}
+			remove[user] = true
+		}
+		for _, user := range t.Assignees {
+			if !remove[user] {
+				assignees = append(assignees, user)
+			}
+		}
+	}
+
+	return t.setAssignees(assignees)
+}
+
+func (t *Task) setAssignees(assignees []string) error {
+	if equalStrings(assignees, t.Assignees) {
+		return nil
+	}
+
+	if len(assignees) == 0 {
+		assignees = nil
+	}
+	t.Assignees = assignees
+	t.UpdatedAt = NowIso()
+	return nil
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseAssignee_Invalid_ShouldFail(t *testing.T) {
+	for _, input := range []string{"", "  ", "none", "two words", "a,b", "waaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaytoolong"} {
+		_, err := ParseAssignee(input)
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%q: expected ValidationError, got %T: %v", input, err, err)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+
+	if err := task.Assign(" carol ", "alice@example.com", "carol"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"alice@example.com", "carol"}; !reflect.DeepEqual(task.Assignees, want) {
+		t.Errorf("expected %v, got %v", want, task.Assignees)
+	}
+	if !task.IsAssignedTo("carol") || task.IsAssignedTo("Carol") {
+		t.Errorf("IsAssignedTo disagrees with %v", task.Assignees)
+	}
+
+	if err := task.Assign("bob", "not valid"); err == nil {
+		t.Fatalf("expected an error for an invalid name")
+	}
+	if len(task.Assignees) != 2 {
+		t.Errorf("expected no change after a failed assignment, got %v", task.Assignees)
+	}
+}
+
+func TestUnassign(t *testing.T) {
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+	_ = task.Assign("alice", "bob", "carol")
+
+	if err := task.Unassign("bob", "dave"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"alice", "carol"}; !reflect.DeepEqual(task.Assignees, want) {
+		t.Errorf("expected %v, got %v", want, task.Assignees)
+	}
+
+	if err := task.Unassign(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Assignees != nil {
+		t.Errorf("expected everyone to be unassigned, got %v", task.Assignees)
+	}
+}
+
+func TestAssign_RecordsHistory(t *testing.T) {
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+	before := task.Clone()
+	_ = task.Assign("bob", "alice")
+	task.RecordChanges(before, refNow, "carol")
+
+	want := Change{At: "2025-03-12T15:04:00Z", Actor: "carol", Field: "assignees", New: "alice bob"}
+	if len(task.History) != 1 || task.History[0] != want {
+		t.Errorf("expected %+v, got %+v", want, task.History)
+	}
+}
+
+func TestAssign_CoexistsWithACustomFieldOfTheSameName(t *testing.T) {
+	fields := newFields(t, []FieldDef{{Name: "assignees"}})
+
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+	before := task.Clone()
+	_ = task.SetField(fields, "assignees", "team-a")
+	_ = task.Assign("alice")
+	task.RecordChanges(before, refNow, "carol")
+
+	if len(task.History) != 2 || task.History[0].Field != "assignees" || task.History[1].Field != "field.assignees" {
+		t.Errorf("expected the built-in and the custom assignees apart, got %+v", task.History)
+	}
+}
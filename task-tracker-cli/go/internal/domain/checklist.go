@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckItem is one step of a task's checklist, lighter than a subtask
+type CheckItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// AddCheckItem appends an unchecked item to the checklist
+func (t *Task) AddCheckItem(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return &ValidationError{Msg: "checklist item cannot be empty"}
+	}
+
+	t.Checklist = append(t.Checklist, CheckItem{Text: text})
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// ToggleCheckItem checks or unchecks the n-th item (1-based) and returns it
+func (t *Task) ToggleCheckItem(n int) (CheckItem, error) {
+	if err := t.checkItemIndex(n); err != nil {
+		return CheckItem{}, err
+	}
+
+	item := &t.Checklist[n-1]
+	item.Done = !item.Done
+	t.UpdatedAt = NowIso()
+	return *item, nil
+}
+
+// RemoveCheckItem drops the n-th item (1-based); the following ones move up
+func (t *Task) RemoveCheckItem(n int) error {
+	if err := t.checkItemIndex(n); err != nil {
+		return err
+	}
+
+	t.Checklist = append(t.Checklist[:n-1], t.Checklist[n:]...)
+	if len(t.Checklist) == 0 {
+		t.Checklist = nil
+	}
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// ChecklistProgress counts the checked items and all items
+func (t *Task) ChecklistProgress() (done, total int) {
+	for _, item := range t.Checklist {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(t.Checklist)
+}
+
+// ChecklistComplete reports whether the task has a checklist and every item is checked
+func (t *Task) ChecklistComplete() bool {
+	done, total := t.ChecklistProgress()
+	return total > 0 && done == total
+}
+
+func (t *Task) checkItemIndex(n int) error {
+	if len(t.Checklist) == 0 {
+		return &ValidationError{Msg: fmt.Sprintf("task %d has no checklist", t.ID)}
+	}
+	if n < 1 || n > len(t.Checklist) {
+		return &ValidationError{Msg: fmt.Sprintf("task %d has no checklist item %d (expected 1-%d)", t.ID, n, len(t.Checklist))}
+	}
+	return nil
+}
+
+// checklistSummary is the history value of a checklist, e.g. "2/5"
+func checklistSummary(t *Task) string {
+	done, total := t.ChecklistProgress()
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", done, total)
+}
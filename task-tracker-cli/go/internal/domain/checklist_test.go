@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChecklist(t *testing.T) {
+	task := Task{ID: 1, Description: "Release"}
+	for _, text := range []string{"Tag", " Build ", "Publish"} {
+		if err := task.AddCheckItem(text); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if task.Checklist[1].Text != "Build" {
+		t.Errorf("expected the item text to be trimmed, got %q", task.Checklist[1].Text)
+	}
+
+	item, err := task.ToggleCheckItem(1)
+	if err != nil || !item.Done {
+		t.Fatalf("expected item 1 checked, got %+v, %v", item, err)
+	}
+	if err := task.RemoveCheckItem(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if done, total := task.ChecklistProgress(); done != 1 || total != 2 || task.ChecklistComplete() {
+		t.Fatalf("expected 1/2, got %d/%d", done, total)
+	}
+
+	if _, err := task.ToggleCheckItem(2); err != nil || !task.ChecklistComplete() {
+		t.Errorf("expected the checklist complete, got %+v, %v", task.Checklist, err)
+	}
+}
+
+func TestChecklist_Errors(t *testing.T) {
+	task := Task{ID: 1, Description: "Release"}
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"empty item", func() error { return task.AddCheckItem("  ") }},
+		{"no checklist", func() error { _, err := task.ToggleCheckItem(1); return err }},
+		{"out of range", func() error {
+			_ = task.AddCheckItem("Tag")
+			return task.RemoveCheckItem(2)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if err := tt.run(); !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestRecordChanges_Checklist(t *testing.T) {
+	task := Task{ID: 1, Description: "Release", Checklist: []CheckItem{{Text: "Tag"}, {Text: "Build"}}}
+	before := task.Clone()
+
+	if _, err := task.ToggleCheckItem(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.RecordChanges(before, refNow, "alice")
+
+	if len(task.History) != 1 || task.History[0].Field != "checklist" || task.History[0].Old != "0/2" || task.History[0].New != "1/2" {
+		t.Errorf("expected the progress to be recorded, got %+v", task.History)
+	}
+	if before.Checklist[1].Done {
+		t.Errorf("expected the clone not to share the checklist")
+	}
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Comment is one note in the discussion thread of a task.
+type Comment struct {
+	At     string `json:"at"` // RFC3339 in UTC like CreatedAt
+	Author string `json:"author,omitempty"`
+	Text   string `json:"text"`
+}
+
+// AddComment appends a comment by author at now; surrounding blank space is trimmed.
+func (t *Task) AddComment(text string, now time.Time, author string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return &ValidationError{Msg: "comment cannot be empty"}
+	}
+
+	t.Comments = append(t.Comments, Comment{At: historyTime(now), Author: author, Text: text})
+	t.UpdatedAt = NowIso()
+	return nil
+}
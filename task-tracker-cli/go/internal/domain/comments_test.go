@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddComment(t *testing.T) {
+	task := Task{ID: 1, Description: "A", Status: StatusTodo}
+
+	if err := task.AddComment("  Waiting on design\n", refNow, "alice"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Comment{At: "2025-03-12T15:04:00Z", Author: "alice", Text: "Waiting on design"}
+	if len(task.Comments) != 1 || task.Comments[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, task.Comments)
+	}
+
+	for _, text := range []string{"", " \n\t"} {
+		err := task.AddComment(text, refNow, "alice")
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%q: expected ValidationError, got %T: %v", text, err, err)
+		}
+	}
+	if len(task.Comments) != 1 {
+		t.Errorf("expected rejected comments to be left out, got %+v", task.Comments)
+	}
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dependencies ("task 3 is blocked by task 1") form a graph across tasks,
+// so like the hierarchy they are checked against the whole collection.
+
+// ValidateBlocker checks that blockerID may block task id:
+// the blocker must exist and the new edge must not close a dependency cycle.
+func ValidateBlocker(tasks []Task, id, blockerID int) error {
+	if blockerID == id {
+		return &ValidationError{Msg: "a task cannot block itself"}
+	}
+
+	byID := indexByID(tasks)
+	if _, ok := byID[blockerID]; !ok {
+		return &ValidationError{Msg: fmt.Sprintf("blocking task %d does not exist", blockerID)}
+	}
+
+	// id waits for blockerID; if blockerID already (transitively) waits for id, the edge closes a cycle
+	if path := dependencyPath(byID, blockerID, id); path != nil {
+		return &ValidationError{Msg: fmt.Sprintf("task %d cannot be blocked by %d: dependency cycle %s", id, blockerID, formatPath(append([]int{id}, path...)))}
+	}
+
+	return nil
+}
+
+// OpenBlockers returns the IDs of t's blockers that are not closed yet in workflow w.
+// Blockers that no longer exist do not block anything.
+func OpenBlockers(w Workflow, tasks []Task, t Task) []int {
+	byID := indexByID(tasks)
+
+	var res []int
+	for _, b := range t.BlockedBy {
+		if blocker, ok := byID[b]; ok && !w.IsClosed(blocker.Status) {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// Dependents returns the tasks that list id as a blocker
+func Dependents(tasks []Task, id int) []Task {
+	var res []Task
+	for _, t := range tasks {
+		if t.IsBlockedBy(id) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// IsBlockedBy reports whether id is one of the task's blockers
+func (t *Task) IsBlockedBy(id int) bool {
+	i := sort.SearchInts(t.BlockedBy, id)
+	return i < len(t.BlockedBy) && t.BlockedBy[i] == id
+}
+
+// AddBlocker records that the task cannot start before blockerID is done.
+// Callers must check ValidateBlocker against the collection first.
+func (t *Task) AddBlocker(blockerID int) error {
+	if blockerID <= 0 {
+		return &ValidationError{Msg: "blocking task id must be positive!"}
+	}
+	if blockerID == t.ID {
+		return &ValidationError{Msg: "a task cannot block itself"}
+	}
+	if t.IsBlockedBy(blockerID) {
+		return nil
+	}
+
+	i := sort.SearchInts(t.BlockedBy, blockerID)
+	t.BlockedBy = append(t.BlockedBy, 0)
+	copy(t.BlockedBy[i+1:], t.BlockedBy[i:])
+	t.BlockedBy[i] = blockerID
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// RemoveBlocker drops blockerID from the task's blockers; removing an absent blocker is a no-op
+func (t *Task) RemoveBlocker(blockerID int) {
+	if !t.IsBlockedBy(blockerID) {
+		return
+	}
+
+	i := sort.SearchInts(t.BlockedBy, blockerID)
+	t.BlockedBy = append(t.BlockedBy[:i], t.BlockedBy[i+1:]...)
+	if len(t.BlockedBy) == 0 {
+		t.BlockedBy = nil
+	}
+	t.UpdatedAt = NowIso()
+}
+
+// dependencyPath returns the chain of blockers leading from `from` to `to`, or nil if there is none.
+func dependencyPath(byID map[int]Task, from, to int) []int {
+	prev := map[int]int{from: 0}
+	queue := []int{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur == to {
+			var path []int
+			for n := to; n != 0; n = prev[n] {
+				path = append([]int{n}, path...)
+			}
+			return path
+		}
+
+		for _, next := range byID[cur].BlockedBy {
+			if _, seen := prev[next]; !seen {
+				prev[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatPath(ids []int) string {
+	return joinIDs(ids, " -> ")
+}
+
+func formatIDs(ids []int) string {
+	return joinIDs(ids, ", ")
+}
+
+func joinIDs(ids []int, sep string) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, sep)
+}
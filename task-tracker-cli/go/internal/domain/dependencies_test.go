@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 3 is blocked by 2, which is blocked by 1 (done); 4 is blocked by 2 and 3
+func dependencies() []Task {
+	return []Task{
+		{ID: 1, Description: "Design", Status: StatusDone},
+		{ID: 2, Description: "Build", Status: StatusInProgress, BlockedBy: []int{1}},
+		{ID: 3, Description: "Deploy", Status: StatusTodo, BlockedBy: []int{2}},
+		{ID: 4, Description: "Announce", Status: StatusTodo, BlockedBy: []int{2, 3}},
+		{ID: 5, Description: "Standalone", Status: StatusTodo},
+	}
+}
+
+func TestValidateBlocker(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        int
+		blockerID int
+		wantErr   string
+	}{
+		{"independent", 5, 4, ""},
+		{"already transitive", 4, 1, ""},
+		{"self", 3, 3, "cannot block itself"},
+		{"missing", 3, 42, "does not exist"},
+		{"direct cycle", 2, 3, "dependency cycle 2 -> 3 -> 2"},
+		{"transitive cycle", 1, 4, "dependency cycle 1 -> 4 -> 2 -> 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBlocker(dependencies(), tt.id, tt.blockerID)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestOpenBlockers(t *testing.T) {
+	tasks := dependencies()
+
+	if open := OpenBlockers(DefaultWorkflow(), tasks, tasks[1]); len(open) != 0 {
+		t.Errorf("blocker 1 is done, expected none open, got %v", open)
+	}
+	if open := OpenBlockers(DefaultWorkflow(), tasks, tasks[3]); !reflect.DeepEqual(open, []int{2, 3}) {
+		t.Errorf("expected [2 3], got %v", open)
+	}
+
+	orphan := Task{ID: 9, BlockedBy: []int{42}}
+	if open := OpenBlockers(DefaultWorkflow(), tasks, orphan); len(open) != 0 {
+		t.Errorf("missing blockers should not block, got %v", open)
+	}
+}
+
+func TestAddRemoveBlocker(t *testing.T) {
+	task, _ := NewTask(5, "Release", StatusTodo)
+
+	for _, b := range []int{3, 1, 2, 3} {
+		if err := task.AddBlocker(b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(task.BlockedBy, []int{1, 2, 3}) {
+		t.Fatalf("expected sorted unique blockers, got %v", task.BlockedBy)
+	}
+
+	if err := task.AddBlocker(5); err == nil {
+		t.Errorf("expected error when a task blocks itself")
+	}
+
+	task.RemoveBlocker(2)
+	task.RemoveBlocker(42)
+	if !reflect.DeepEqual(task.BlockedBy, []int{1, 3}) {
+		t.Errorf("expected [1 3], got %v", task.BlockedBy)
+	}
+
+	task.RemoveBlocker(1)
+	task.RemoveBlocker(3)
+	if task.BlockedBy != nil {
+		t.Errorf("expected no blockers, got %v", task.BlockedBy)
+	}
+}
+
+func TestMarkInProgress_Blocked_ShouldFail(t *testing.T) {
+	task, _ := NewTask(3, "Deploy", StatusTodo)
+
+	err := task.MarkInProgress(DefaultWorkflow(), []int{2})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	if task.Status != StatusTodo {
+		t.Errorf("status should remain todo, got %s", task.Status)
+	}
+}
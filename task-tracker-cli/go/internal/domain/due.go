@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the calendar date format used for due dates
+const DateLayout = "2006-01-02"
+
+// ParseDueDate turns user input into a calendar date relative to now.
+// Accepted forms: ISO dates (2025-03-31), today, tomorrow, +3d, +2w, +1m,
+// weekday names (friday: today or the coming one) and "next friday" (strictly after today).
+func ParseDueDate(input string, now time.Time) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch s {
+	case "today":
+		return today.Format(DateLayout), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1).Format(DateLayout), nil
+	}
+
+	if strings.HasPrefix(s, "+") && len(s) > 2 {
+		n, err := strconv.Atoi(s[1 : len(s)-1])
+		if err == nil && n >= 0 {
+			switch s[len(s)-1] {
+			case 'd':
+				return today.AddDate(0, 0, n).Format(DateLayout), nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n).Format(DateLayout), nil
+			case 'm':
+				// like monthly recurrences, stay in the target month: Jan 31 +1m is the end of February
+				return dayOfMonth(today.Year(), today.Month()+time.Month(n), today.Day()).Format(DateLayout), nil
+			}
+		}
+	}
+
+	next := strings.HasPrefix(s, "next ")
+	if wd, ok := parseWeekday(strings.TrimPrefix(s, "next ")); ok {
+		days := (int(wd) - int(today.Weekday()) + 7) % 7
+		if next && days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days).Format(DateLayout), nil
+	}
+
+	if d, err := time.Parse(DateLayout, s); err == nil {
+		return d.Format(DateLayout), nil
+	}
+
+	return "", &ValidationError{Msg: fmt.Sprintf("invalid date %q (expected: YYYY-MM-DD, today, tomorrow, +3d, +2w, +1m, friday, next friday)", input)}
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if s == name || s == name[:3] {
+			return wd, true
+		}
+	}
+
+	return 0, false
+}
+
+// SetDue sets the due date (YYYY-MM-DD) or clears it when due is empty
+func (t *Task) SetDue(due string) error {
+	if due != "" {
+		if _, err := time.Parse(DateLayout, due); err != nil {
+			return &ValidationError{Msg: fmt.Sprintf("invalid due date %q (expected: YYYY-MM-DD)", due)}
+		}
+	}
+	if t.Due == due {
+		return nil
+	}
+
+	t.Due = due
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// IsOverdue reports whether a task that is open in workflow w was due before the day of now
+func (t *Task) IsOverdue(w Workflow, now time.Time) bool {
+	if t.Due == "" || w.IsClosed(t.Status) {
+		return false
+	}
+
+	return t.Due < now.Format(DateLayout)
+}
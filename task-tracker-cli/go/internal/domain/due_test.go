@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Wednesday
+var refNow = time.Date(2025, 3, 12, 15, 4, 0, 0, time.UTC)
+
+func TestParseDueDate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"2025-04-01", "2025-04-01"},
+		{"today", "2025-03-12"},
+		{"Tomorrow", "2025-03-13"},
+		{"+3d", "2025-03-15"},
+		{"+2w", "2025-03-26"},
+		{"+1m", "2025-04-12"},
+		{"friday", "2025-03-14"},
+		{"fri", "2025-03-14"},
+		{"wednesday", "2025-03-12"},
+		{"next wednesday", "2025-03-19"},
+		{"next friday", "2025-03-14"},
+		{"monday", "2025-03-17"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDueDate(tt.input, refNow)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDueDate_MonthsClampToTheEndOfTheMonth(t *testing.T) {
+	tests := []struct {
+		now   time.Time
+		input string
+		want  string
+	}{
+		{time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), "+1m", "2025-02-28"},
+		{time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), "+1m", "2024-02-29"},
+		{time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC), "+1m", "2025-04-30"},
+		{time.Date(2025, 8, 31, 9, 0, 0, 0, time.UTC), "+6m", "2026-02-28"},
+		{time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), "+0m", "2025-01-31"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDueDate(tt.input, tt.now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("%s %s: expected %s, got %s", tt.now.Format(DateLayout), tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestParseDueDate_Invalid_ShouldFail(t *testing.T) {
+	for _, input := range []string{"", "soon", "+3", "+xd", "2025-13-01", "next"} {
+		_, err := ParseDueDate(input, refNow)
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%q: expected ValidationError, got %T: %v", input, err, err)
+		}
+	}
+}
+
+func TestSetDue(t *testing.T) {
+	task, _ := NewTask(1, "Ship release", StatusTodo)
+
+	if err := task.SetDue("2025-03-31"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Due != "2025-03-31" {
+		t.Errorf("expected due date to be set, got %q", task.Due)
+	}
+
+	if err := task.SetDue("tomorrow"); err == nil {
+		t.Fatalf("expected error for non-ISO date")
+	}
+
+	if err := task.SetDue(""); err != nil || task.Due != "" {
+		t.Errorf("expected due date to be cleared, got %q, %v", task.Due, err)
+	}
+}
+
+func TestIsOverdue(t *testing.T) {
+	tests := []struct {
+		name string
+		task Task
+		want bool
+	}{
+		{"no due date", Task{Status: StatusTodo}, false},
+		{"due yesterday", Task{Status: StatusTodo, Due: "2025-03-11"}, true},
+		{"due today", Task{Status: StatusInProgress, Due: "2025-03-12"}, false},
+		{"due tomorrow", Task{Status: StatusTodo, Due: "2025-03-13"}, false},
+		{"done late", Task{Status: StatusDone, Due: "2025-03-01"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.IsOverdue(DefaultWorkflow(), refNow); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
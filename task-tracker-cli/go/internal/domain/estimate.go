@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estimate is the expected size of a task, either in story points or as a duration.
+// Sums of estimates may carry both.
+type Estimate struct {
+	Points   float64
+	Duration time.Duration
+}
+
+// ParseEstimate parses story points such as 3 or 0.5, or a duration such as 4h, 1h30m or 45m
+func ParseEstimate(input string) (Estimate, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	if p, err := strconv.ParseFloat(strings.TrimSuffix(s, "pt"), 64); err == nil {
+		if !(p > 0) || math.IsInf(p, 0) {
+			return Estimate{}, &ValidationError{Msg: fmt.Sprintf("invalid estimate %q (points must be a positive number)", input)}
+		}
+		return Estimate{Points: p}, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		return Estimate{}, &ValidationError{Msg: fmt.Sprintf("invalid estimate %q (expected points like 3 or a duration like 4h, 1h30m)", input)}
+	}
+	return Estimate{Duration: d.Round(time.Minute)}, nil
+}
+
+// String renders the canonical form stored on tasks: "3" for points, "1h30m" for durations
+func (e Estimate) String() string {
+	var parts []string
+	if e.Points != 0 {
+		parts = append(parts, strconv.FormatFloat(e.Points, 'f', -1, 64))
+	}
+	if e.Duration != 0 {
+		m := int(e.Duration.Round(time.Minute).Minutes())
+		switch {
+		case m < 60:
+			parts = append(parts, fmt.Sprintf("%dm", m))
+		case m%60 == 0:
+			parts = append(parts, fmt.Sprintf("%dh", m/60))
+		default:
+			parts = append(parts, fmt.Sprintf("%dh%dm", m/60, m%60))
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// IsZero reports whether nothing is estimated
+func (e Estimate) IsZero() bool {
+	return e.Points == 0 && e.Duration == 0
+}
+
+// Add sums two estimates
+func (e Estimate) Add(o Estimate) Estimate {
+	return Estimate{Points: e.Points + o.Points, Duration: e.Duration + o.Duration}
+}
+
+// SetEstimate sizes the task; "" or "none" removes the estimate
+func (t *Task) SetEstimate(input string) error {
+	canonical := ""
+	if s := strings.TrimSpace(input); s != "" && s != "none" {
+		e, err := ParseEstimate(s)
+		if err != nil {
+			return err
+		}
+		canonical = e.String()
+	}
+	if t.Estimate == canonical {
+		return nil
+	}
+
+	t.Estimate = canonical
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// EstimateValue returns the parsed estimate of the task; zero when it has none
+func (t *Task) EstimateValue() Estimate {
+	if t.Estimate == "" {
+		return Estimate{}
+	}
+	e, _ := ParseEstimate(t.Estimate) // validated when set
+	return e
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Estimate
+		str   string // canonical form; "" when the input is refused
+	}{
+		{"3", Estimate{Points: 3}, "3"},
+		{"0.5pt", Estimate{Points: 0.5}, "0.5"},
+		{"4h", Estimate{Duration: 4 * time.Hour}, "4h"},
+		{"90m", Estimate{Duration: 90 * time.Minute}, "1h30m"},
+		{"45M", Estimate{Duration: 45 * time.Minute}, "45m"},
+		{"0", Estimate{}, ""},
+		{"-2", Estimate{}, ""},
+		{"30s", Estimate{}, ""},
+		{"big", Estimate{}, ""},
+		{"NaN", Estimate{}, ""},
+		{"inf", Estimate{}, ""},
+		{"-Inf", Estimate{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseEstimate(tt.input)
+			if tt.str == "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want || got.String() != tt.str {
+				t.Errorf("expected %+v (%s), got %+v (%s)", tt.want, tt.str, got, got)
+			}
+		})
+	}
+}
+
+func TestSetEstimate(t *testing.T) {
+	task := Task{ID: 1, Description: "A", Status: StatusTodo}
+	before := task.Clone()
+
+	if err := task.SetEstimate("120m"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Estimate != "2h" || task.EstimateValue().Duration != 2*time.Hour {
+		t.Fatalf("expected a canonical 2h estimate, got %q", task.Estimate)
+	}
+	if err := task.Validate(DefaultWorkflow(), FieldSet{}); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	task.RecordChanges(before, refNow, "alice")
+	if len(task.History) != 1 || task.History[0].Field != "estimate" || task.History[0].New != "2h" {
+		t.Errorf("expected the estimate to be recorded, got %+v", task.History)
+	}
+
+	if err := task.SetEstimate("none"); err != nil || task.Estimate != "" || !task.EstimateValue().IsZero() {
+		t.Errorf("expected the estimate removed, got %q, %v", task.Estimate, err)
+	}
+}
+
+func TestEstimate_AddMixesUnits(t *testing.T) {
+	sum := Estimate{Points: 3}.Add(Estimate{Duration: 90 * time.Minute}).Add(Estimate{Points: 2})
+	if sum.String() != "5 + 1h30m" {
+		t.Errorf("expected 5 + 1h30m, got %s", sum)
+	}
+}
+
+func TestEstimate_CoexistsWithACustomFieldOfTheSameName(t *testing.T) {
+	fields := newFields(t, []FieldDef{{Name: "estimate", Type: FieldNumber}})
+
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+	before := task.Clone()
+	if err := task.SetField(fields, "estimate", "3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := task.SetEstimate("2h"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	task.RecordChanges(before, refNow, "alice")
+
+	if len(task.History) != 2 || task.History[0].Field != "estimate" || task.History[0].New != "2h" ||
+		task.History[1].Field != "field.estimate" || task.History[1].New != "3" {
+		t.Errorf("expected the built-in and the custom estimate apart, got %+v", task.History)
+	}
+}
@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of the values a custom field accepts
+type FieldType string
+
+const (
+	FieldString FieldType = "string"
+	FieldNumber FieldType = "number"
+	FieldDate   FieldType = "date" // YYYY-MM-DD
+	FieldBool   FieldType = "bool"
+)
+
+// FieldDef declares a custom field that tasks may carry, e.g. ticket (string) or estimate (number)
+type FieldDef struct {
+	Name string
+	Type FieldType
+}
+
+// reservedFieldNames are the names list --sort reads as a built-in order before looking up a field.
+// Any other name is free: the history records custom fields apart from built-in attributes (see FieldHistoryKey).
+var reservedFieldNames = map[string]bool{"id": true, "priority": true}
+
+// fieldHistoryPrefix namespaces the history entries of custom fields
+const fieldHistoryPrefix = "field."
+
+// FieldHistoryKey is the name the changes of a custom field are recorded under in the history
+func FieldHistoryKey(name string) string {
+	return fieldHistoryPrefix + name
+}
+
+// FieldSet is the set of custom fields tasks may carry; the zero value declares none
+type FieldSet struct {
+	defs []FieldDef
+}
+
+// NewFieldSet declares the custom fields tasks may carry after validating them.
+// The type defaults to string.
+func NewFieldSet(defs []FieldDef) (FieldSet, error) {
+	defs = append([]FieldDef(nil), defs...)
+	seen := map[string]bool{}
+	for i, d := range defs {
+		if d.Type == "" {
+			defs[i].Type = FieldString
+		}
+		switch {
+		case !validFieldName(d.Name):
+			return FieldSet{}, &ValidationError{Msg: fmt.Sprintf("invalid field name %q (letters, digits, '-' and '_', starting with a letter)", d.Name)}
+		case reservedFieldNames[d.Name]:
+			return FieldSet{}, &ValidationError{Msg: fmt.Sprintf("field %q is reserved for sorting (list --sort %s)", d.Name, d.Name)}
+		case seen[d.Name]:
+			return FieldSet{}, &ValidationError{Msg: fmt.Sprintf("field %q is declared twice", d.Name)}
+		}
+		switch defs[i].Type {
+		case FieldString, FieldNumber, FieldDate, FieldBool:
+		default:
+			return FieldSet{}, &ValidationError{Msg: fmt.Sprintf("invalid type %q of field %q (expected: string|number|date|bool)", d.Type, d.Name)}
+		}
+		seen[d.Name] = true
+	}
+
+	return FieldSet{defs: defs}, nil
+}
+
+// Defs lists the declared custom fields in declaration order
+func (fs FieldSet) Defs() []FieldDef {
+	return append([]FieldDef(nil), fs.defs...)
+}
+
+// Lookup finds a declared custom field by name
+func (fs FieldSet) Lookup(name string) (FieldDef, bool) {
+	for _, d := range fs.defs {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return FieldDef{}, false
+}
+
+// Parse validates a raw value and returns its canonical form:
+// numbers without superfluous digits, dates as YYYY-MM-DD and booleans as true/false.
+func (d FieldDef) Parse(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch d.Type {
+	case FieldNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", &ValidationError{Msg: fmt.Sprintf("field %s expects a number, got %q", d.Name, raw)}
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case FieldDate:
+		if _, err := time.Parse(DateLayout, raw); err != nil {
+			return "", &ValidationError{Msg: fmt.Sprintf("field %s expects a date (YYYY-MM-DD), got %q", d.Name, raw)}
+		}
+		return raw, nil
+	case FieldBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "", &ValidationError{Msg: fmt.Sprintf("field %s expects true or false, got %q", d.Name, raw)}
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		if raw == "" {
+			return "", &ValidationError{Msg: fmt.Sprintf("field %s cannot be empty", d.Name)}
+		}
+		return raw, nil
+	}
+}
+
+// Compare orders two canonical values of the field: numerically, chronologically, false before true,
+// or lexicographically for strings.
+func (d FieldDef) Compare(a, b string) int {
+	if d.Type == FieldNumber {
+		x, _ := strconv.ParseFloat(a, 64)
+		y, _ := strconv.ParseFloat(b, 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// ParseFieldAssignment splits "key=value"; an empty value unsets the field.
+func ParseFieldAssignment(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return "", "", &ValidationError{Msg: fmt.Sprintf("invalid field assignment %q (expected: key=value)", s)}
+	}
+	return strings.TrimSpace(name), value, nil
+}
+
+// SetField sets a custom field declared in fields; an empty value removes it.
+func (t *Task) SetField(fields FieldSet, name, value string) error {
+	def, ok := fields.Lookup(name)
+	if !ok {
+		return fields.unknown(name)
+	}
+
+	if strings.TrimSpace(value) == "" {
+		if _, set := t.Fields[name]; !set {
+			return nil
+		}
+		delete(t.Fields, name)
+		if len(t.Fields) == 0 {
+			t.Fields = nil
+		}
+		t.UpdatedAt = NowIso()
+		return nil
+	}
+
+	v, err := def.Parse(value)
+	if err != nil {
+		return err
+	}
+	if cur, set := t.Fields[name]; set && cur == v {
+		return nil
+	}
+
+	if t.Fields == nil {
+		t.Fields = map[string]string{}
+	}
+	t.Fields[name] = v
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// FieldNames returns the names of the custom fields set on the task in alphabetical order
+func (t *Task) FieldNames() []string {
+	names := make([]string, 0, len(t.Fields))
+	for name := range t.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks the values of the task's fields declared in fs.
+// Values of fields that are no longer declared are kept as they are.
+func (fs FieldSet) validate(t *Task) error {
+	for _, name := range t.FieldNames() {
+		def, ok := fs.Lookup(name)
+		if !ok {
+			continue
+		}
+		if v, err := def.Parse(t.Fields[name]); err != nil || v != t.Fields[name] {
+			return &ValidationError{Msg: fmt.Sprintf("invalid value %q of field %s", t.Fields[name], name)}
+		}
+	}
+	return nil
+}
+
+func (fs FieldSet) unknown(name string) error {
+	names := make([]string, len(fs.defs))
+	for i, d := range fs.defs {
+		names[i] = d.Name
+	}
+	if len(names) == 0 {
+		return &ValidationError{Msg: fmt.Sprintf("unknown field %q (no custom fields are configured)", name)}
+	}
+	return &ValidationError{Msg: fmt.Sprintf("unknown field %q (expected: %s)", name, strings.Join(names, "|"))}
+}
+
+func validFieldName(name string) bool {
+	if name == "" || !isLetter(name[0]) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isLetter(c) && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
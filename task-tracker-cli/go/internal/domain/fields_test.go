@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewFieldSet_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []FieldDef
+	}{
+		{"invalid name", []FieldDef{{Name: "two words"}}},
+		{"starts with a digit", []FieldDef{{Name: "1st"}}},
+		{"sort key", []FieldDef{{Name: "priority", Type: FieldNumber}}},
+		{"duplicate", []FieldDef{{Name: "ticket"}, {Name: "ticket"}}},
+		{"unknown type", []FieldDef{{Name: "ticket", Type: "url"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr *ValidationError
+			if _, err := NewFieldSet(tt.defs); !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestSetField(t *testing.T) {
+	fields := newFields(t, []FieldDef{
+		{Name: "ticket"},
+		{Name: "estimate", Type: FieldNumber},
+		{Name: "signed", Type: FieldDate},
+		{Name: "billable", Type: FieldBool},
+	})
+
+	tests := []struct {
+		name, value string
+		want        string // canonical value; "" when the value is refused
+	}{
+		{"ticket", " JIRA-123 ", "JIRA-123"},
+		{"estimate", "3.50", "3.5"},
+		{"estimate", "three", ""},
+		{"estimate", "NaN", ""},
+		{"estimate", "-Inf", ""},
+		{"signed", "2025-03-31", "2025-03-31"},
+		{"signed", "31/03/2025", ""},
+		{"billable", "1", "true"},
+		{"billable", "maybe", ""},
+		{"customer", "acme", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			task := Task{ID: 1, Description: "A"}
+			err := task.SetField(fields, tt.name, tt.value)
+			if tt.want == "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := task.Fields[tt.name]; got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetField_EmptyValueUnsets(t *testing.T) {
+	fields := newFields(t, []FieldDef{{Name: "ticket"}})
+	task := Task{ID: 1, Description: "A", Fields: map[string]string{"ticket": "JIRA-1"}}
+	before := task.Clone()
+
+	if err := task.SetField(fields, "ticket", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Fields != nil || before.Fields["ticket"] != "JIRA-1" {
+		t.Fatalf("expected the field removed from the task only, got %v and %v", task.Fields, before.Fields)
+	}
+
+	task.RecordChanges(before, refNow, "alice")
+	if len(task.History) != 1 || task.History[0].Field != "field.ticket" || task.History[0].Old != "JIRA-1" {
+		t.Errorf("expected the removal to be recorded, got %+v", task.History)
+	}
+}
+
+func TestRecordChanges_KeepsAFieldNamedLikeAnAttributeApart(t *testing.T) {
+	fields := newFields(t, []FieldDef{{Name: "status"}})
+	task := Task{ID: 1, Description: "A", Status: StatusTodo}
+	before := task.Clone()
+
+	task.Status = StatusDone
+	if err := task.SetField(fields, "status", "signed off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	task.RecordChanges(before, refNow, "alice")
+	if len(task.History) != 2 || task.History[0].Field != "status" || task.History[1].Field != "field.status" {
+		t.Errorf("expected the attribute and the field recorded apart, got %+v", task.History)
+	}
+}
+
+func TestFieldDef_Compare(t *testing.T) {
+	number := FieldDef{Name: "estimate", Type: FieldNumber}
+	if number.Compare("9", "10") >= 0 {
+		t.Errorf("expected numbers to compare numerically")
+	}
+	date := FieldDef{Name: "signed", Type: FieldDate}
+	if date.Compare("2025-01-31", "2025-02-01") >= 0 {
+		t.Errorf("expected dates to compare chronologically")
+	}
+}
+
+func TestValidate_Fields(t *testing.T) {
+	fields := newFields(t, []FieldDef{{Name: "estimate", Type: FieldNumber}})
+
+	task := Task{ID: 1, Description: "A", Status: StatusTodo, Fields: map[string]string{"estimate": "lots", "legacy": "x"}}
+	var validationErr *ValidationError
+	if err := task.Validate(DefaultWorkflow(), fields); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for an invalid number, got %T: %v", err, err)
+	}
+
+	task.Fields["estimate"] = "2"
+	if err := task.Validate(DefaultWorkflow(), fields); err != nil {
+		t.Errorf("expected undeclared fields to be kept, got %v", err)
+	}
+}
+
+// newFields declares custom fields, failing the test if they are invalid
+func newFields(t *testing.T, defs []FieldDef) FieldSet {
+	t.Helper()
+	fields, err := NewFieldSet(defs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fields
+}
@@ -0,0 +1,158 @@
+package domain
+
+import "fmt"
+
+// Parent/child invariants span several tasks, so they are checked against the whole collection.
+
+// ValidateParent checks that task id may be placed under parentID:
+// the parent must exist and the move must not create a cycle. parentID 0 means "no parent".
+func ValidateParent(tasks []Task, id, parentID int) error {
+	if parentID == 0 {
+		return nil
+	}
+	if parentID == id {
+		return &ValidationError{Msg: "a task cannot be its own parent"}
+	}
+
+	byID := indexByID(tasks)
+	if _, ok := byID[parentID]; !ok {
+		return &ValidationError{Msg: fmt.Sprintf("parent task %d does not exist", parentID)}
+	}
+
+	// Walk up from the new parent; reaching id means id would become its own ancestor
+	seen := map[int]bool{}
+	for cur := parentID; cur != 0; cur = byID[cur].ParentID {
+		if cur == id {
+			return &ValidationError{Msg: fmt.Sprintf("task %d cannot be moved under its own subtask %d", id, parentID)}
+		}
+		if seen[cur] {
+			break // pre-existing corruption, not caused by this move
+		}
+		seen[cur] = true
+	}
+
+	return nil
+}
+
+// Children returns the direct subtasks of id
+func Children(tasks []Task, id int) []Task {
+	var res []Task
+	for _, t := range tasks {
+		if t.ParentID == id && t.ID != id {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// OpenDescendants returns every subtask below id (at any depth) that is not closed in workflow w
+func OpenDescendants(w Workflow, tasks []Task, id int) []Task {
+	var res []Task
+	walkDescendants(tasks, id, func(t Task) {
+		if !w.IsClosed(t.Status) {
+			res = append(res, t)
+		}
+	})
+	return res
+}
+
+// Progress counts the subtasks below a task (at any depth) and how many of them are done; cancelled ones are not counted
+type Progress struct {
+	Done, Total int
+}
+
+// SubtaskProgress computes the Progress of every task in a single pass over the hierarchy
+func SubtaskProgress(w Workflow, tasks []Task) map[int]Progress {
+	children := map[int][]Task{}
+	for _, t := range tasks {
+		if t.ParentID != 0 {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		}
+	}
+
+	res := make(map[int]Progress, len(tasks))
+	visiting := map[int]bool{}
+	var count func(id int) Progress
+	count = func(id int) Progress {
+		if p, ok := res[id]; ok {
+			return p
+		}
+		if visiting[id] {
+			return Progress{} // corrupted cycle; count nothing twice
+		}
+		visiting[id] = true
+
+		var p Progress
+		for _, c := range children[id] {
+			sub := count(c.ID)
+			p.Total += sub.Total
+			p.Done += sub.Done
+			switch category, _ := w.Category(c.Status); category {
+			case CategoryCancelled:
+				// dropped work counts neither way
+			case CategoryDone:
+				p.Total++
+				p.Done++
+			default:
+				p.Total++
+			}
+		}
+
+		res[id] = p
+		return p
+	}
+
+	for _, t := range tasks {
+		count(t.ID)
+	}
+	return res
+}
+
+// SetParent attaches the task to parentID (0 detaches it).
+// Callers must check ValidateParent against the collection first.
+func (t *Task) SetParent(parentID int) error {
+	if parentID < 0 {
+		return &ValidationError{Msg: "parent id must be positive!"}
+	}
+	if parentID == t.ID {
+		return &ValidationError{Msg: "a task cannot be its own parent"}
+	}
+	if t.ParentID == parentID {
+		return nil
+	}
+
+	t.ParentID = parentID
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+func walkDescendants(tasks []Task, id int, visit func(Task)) {
+	children := map[int][]Task{}
+	for _, t := range tasks {
+		if t.ParentID != 0 {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		}
+	}
+
+	seen := map[int]bool{id: true}
+	stack := append([]Task(nil), children[id]...)
+	for len(stack) > 0 {
+		t := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[t.ID] {
+			continue
+		}
+		seen[t.ID] = true
+
+		visit(t)
+		stack = append(stack, children[t.ID]...)
+	}
+}
+
+func indexByID(tasks []Task) map[int]Task {
+	byID := make(map[int]Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+	return byID
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// 1
+// ├── 2
+// │   └── 4 (done)
+// └── 3 (done)
+// 5
+func hierarchy() []Task {
+	return []Task{
+		{ID: 1, Description: "Epic", Status: StatusTodo},
+		{ID: 2, Description: "Story", Status: StatusInProgress, ParentID: 1},
+		{ID: 3, Description: "Story", Status: StatusDone, ParentID: 1},
+		{ID: 4, Description: "Subtask", Status: StatusDone, ParentID: 2},
+		{ID: 5, Description: "Standalone", Status: StatusTodo},
+	}
+}
+
+func TestValidateParent(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       int
+		parentID int
+		ok       bool
+	}{
+		{"detach", 2, 0, true},
+		{"move under sibling tree", 5, 4, true},
+		{"missing parent", 5, 42, false},
+		{"self", 2, 2, false},
+		{"direct child", 1, 2, false},
+		{"grandchild", 1, 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParent(hierarchy(), tt.id, tt.parentID)
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenDescendants(t *testing.T) {
+	open := OpenDescendants(DefaultWorkflow(), hierarchy(), 1)
+	if len(open) != 1 || open[0].ID != 2 {
+		t.Errorf("expected only task 2 open below 1, got %+v", open)
+	}
+
+	if open := OpenDescendants(DefaultWorkflow(), hierarchy(), 2); len(open) != 0 {
+		t.Errorf("expected no open subtasks below 2, got %+v", open)
+	}
+}
+
+func TestSubtaskProgress(t *testing.T) {
+	tasks := append(hierarchy(), Task{ID: 6, Description: "Dropped", Status: StatusCancelled, ParentID: 2})
+	progress := SubtaskProgress(DefaultWorkflow(), tasks)
+
+	if p := progress[1]; p != (Progress{Done: 2, Total: 3}) {
+		t.Errorf("expected 2/3 below 1, got %d/%d", p.Done, p.Total)
+	}
+	if p := progress[2]; p != (Progress{Done: 1, Total: 1}) {
+		t.Errorf("expected 1/1 below 2 without the cancelled subtask, got %d/%d", p.Done, p.Total)
+	}
+	if p := progress[5]; p != (Progress{}) {
+		t.Errorf("expected 0/0 for a leaf, got %d/%d", p.Done, p.Total)
+	}
+
+	// a corrupted cycle must not loop forever
+	cycle := []Task{{ID: 1, Status: StatusTodo, ParentID: 2}, {ID: 2, Status: StatusDone, ParentID: 1}}
+	if p := SubtaskProgress(DefaultWorkflow(), cycle)[1]; p.Total > 2 {
+		t.Errorf("expected each task counted at most once, got %d/%d", p.Done, p.Total)
+	}
+}
+
+func TestSetParent_Self_ShouldFail(t *testing.T) {
+	task, _ := NewTask(1, "Epic", StatusTodo)
+
+	if err := task.SetParent(1); err == nil {
+		t.Fatalf("expected error when parenting a task to itself")
+	}
+	if task.ParentID != 0 {
+		t.Errorf("parent should remain unset, got %d", task.ParentID)
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change is one entry of a task's append-only history.
+// Old and New are display values; an empty value means the field was unset.
+type Change struct {
+	At    string `json:"at"` // RFC3339 in UTC like CreatedAt
+	Actor string `json:"actor,omitempty"`
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// FieldCreated marks the first history entry of a task; New holds its description
+const FieldCreated = "created"
+
+// trackedFields are the fields whose changes are recorded in the history.
+// Time entries and comments are logs of their own and are not repeated there.
+var trackedFields = []struct {
+	name  string
+	value func(t *Task) string
+}{
+	{"description", func(t *Task) string { return t.Description }},
+	{"status", func(t *Task) string { return string(t.Status) }},
+	{"priority", func(t *Task) string { return string(t.Priority) }},
+	{"due", func(t *Task) string { return t.Due }},
+	{"tags", func(t *Task) string { return strings.Join(t.Tags, " ") }},
+	{"parent", func(t *Task) string { return optionalID(t.ParentID) }},
+	{"blockedBy", func(t *Task) string { return joinIDs(t.BlockedBy, " ") }},
+	{"recurrence", func(t *Task) string { return t.Recurrence }},
+	{"deletedAt", func(t *Task) string { return t.DeletedAt }},
+	{"project", func(t *Task) string { return t.ProjectName() }},
+	{"checklist", checklistSummary},
+	{"estimate", func(t *Task) string { return t.Estimate }},
+	{"assignees", func(t *Task) string { return strings.Join(t.Assignees, " ") }},
+}
+
+// RecordCreated starts the history of a new task
+func (t *Task) RecordCreated(at time.Time, actor string) {
+	t.History = append(t.History, Change{At: historyTime(at), Actor: actor, Field: FieldCreated, New: t.Description})
+}
+
+// RecordChanges appends an entry for every tracked field that differs from before.
+// before must be a Clone taken ahead of the mutation.
+func (t *Task) RecordChanges(before Task, at time.Time, actor string) {
+	for _, f := range trackedFields {
+		if old, cur := f.value(&before), f.value(t); old != cur {
+			t.History = append(t.History, Change{At: historyTime(at), Actor: actor, Field: f.name, Old: old, New: cur})
+		}
+	}
+
+	// custom fields are recorded under field.<name>, apart from built-in attributes of the same name
+	names := t.FieldNames()
+	for _, name := range before.FieldNames() {
+		if _, set := t.Fields[name]; !set {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if old, cur := before.Fields[name], t.Fields[name]; old != cur {
+			t.History = append(t.History, Change{At: historyTime(at), Actor: actor, Field: FieldHistoryKey(name), Old: old, New: cur})
+		}
+	}
+}
+
+// Clone returns a deep copy, so that mutating the task does not alter the copy through shared slices
+func (t Task) Clone() Task {
+	t.Tags = cloneSlice(t.Tags)
+	t.Assignees = cloneSlice(t.Assignees)
+	t.BlockedBy = cloneSlice(t.BlockedBy)
+	t.TimeEntries = cloneSlice(t.TimeEntries)
+	t.History = cloneSlice(t.History)
+	t.Comments = cloneSlice(t.Comments)
+	t.Fields = maps.Clone(t.Fields)
+	t.Checklist = cloneSlice(t.Checklist)
+	return t
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func historyTime(at time.Time) string {
+	return at.UTC().Format(time.RFC3339)
+}
+
+func optionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestRecordChanges(t *testing.T) {
+	task, _ := NewTask(1, "Buy milk", StatusTodo)
+	task.RecordCreated(refNow, "alice")
+
+	before := task.Clone()
+	_ = task.UpdateDescription("Buy oat milk")
+	_ = task.UpdateTags([]string{"home"}, nil)
+	_ = task.AddBlocker(3)
+	_ = task.SetDue("2025-03-14")
+	task.RecordChanges(before, refNow, "bob")
+
+	want := []Change{
+		{At: "2025-03-12T15:04:00Z", Actor: "alice", Field: FieldCreated, New: "Buy milk"},
+		{At: "2025-03-12T15:04:00Z", Actor: "bob", Field: "description", Old: "Buy milk", New: "Buy oat milk"},
+		{At: "2025-03-12T15:04:00Z", Actor: "bob", Field: "due", New: "2025-03-14"},
+		{At: "2025-03-12T15:04:00Z", Actor: "bob", Field: "tags", New: "home"},
+		{At: "2025-03-12T15:04:00Z", Actor: "bob", Field: "blockedBy", New: "3"},
+	}
+	if len(task.History) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), task.History)
+	}
+	for i := range want {
+		if task.History[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], task.History[i])
+		}
+	}
+}
+
+func TestRecordChanges_NothingChanged(t *testing.T) {
+	task, _ := NewTask(1, "Buy milk", StatusTodo)
+	before := task.Clone()
+
+	_ = task.SetPriority(task.Priority)
+	_ = task.StartTimer(DefaultWorkflow(), refNow)
+	task.RecordChanges(before, refNow, "alice")
+
+	if len(task.History) != 0 {
+		t.Errorf("expected no history, got %+v", task.History)
+	}
+}
+
+func TestClone_DoesNotShareSlices(t *testing.T) {
+	task := Task{ID: 5, BlockedBy: make([]int, 2, 8)}
+	task.BlockedBy[0], task.BlockedBy[1] = 1, 3
+
+	clone := task.Clone()
+	_ = task.AddBlocker(2) // fits the spare capacity and shifts in place
+
+	if clone.BlockedBy[1] != 3 {
+		t.Errorf("clone changed through a shared slice: %v", clone.BlockedBy)
+	}
+}
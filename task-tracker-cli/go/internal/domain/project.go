@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultProject holds every task that was not put in a named project.
+// It always exists and is stored as an empty Task.Project.
+const DefaultProject = "inbox"
+
+// Project is a named list of tasks within one store.
+type Project struct {
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// NewProject validates name and creates the project at now.
+func NewProject(name string, now time.Time) (Project, error) {
+	name, err := ParseProjectName(name)
+	if err != nil {
+		return Project{}, err
+	}
+	if name == DefaultProject {
+		return Project{}, &ValidationError{Msg: fmt.Sprintf("project %s always exists", DefaultProject)}
+	}
+
+	return Project{Name: name, CreatedAt: historyTime(now)}, nil
+}
+
+// ParseProjectName trims a project name and checks it is a single word.
+func ParseProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\n,|") {
+		return "", &ValidationError{Msg: fmt.Sprintf("invalid project name %q (a single word without ',' or '|')", name)}
+	}
+	return name, nil
+}
+
+// ProjectName returns the project the task belongs to.
+func (t *Task) ProjectName() string {
+	if t.Project == "" {
+		return DefaultProject
+	}
+	return t.Project
+}
+
+// SetProject moves the task to the named project; DefaultProject or "" moves it to the default one.
+func (t *Task) SetProject(name string) error {
+	if name == DefaultProject {
+		name = ""
+	}
+	if name != "" {
+		var err error
+		if name, err = ParseProjectName(name); err != nil {
+			return err
+		}
+	}
+	if t.Project == name {
+		return nil
+	}
+
+	t.Project = name
+	t.UpdatedAt = NowIso()
+	return nil
+}
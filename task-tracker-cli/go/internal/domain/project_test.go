@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewProject(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // "" when the name is refused
+	}{
+		{"api", "api"},
+		{"  web-app ", "web-app"},
+		{"", ""},
+		{"two words", ""},
+		{"a|b", ""},
+		{DefaultProject, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProject(tt.name, refNow)
+			if tt.want == "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Name != tt.want || p.CreatedAt != "2025-03-12T15:04:00Z" {
+				t.Errorf("expected %s created at refNow, got %+v", tt.want, p)
+			}
+		})
+	}
+}
+
+func TestSetProject(t *testing.T) {
+	task := Task{ID: 1, Description: "A"}
+	if task.ProjectName() != DefaultProject {
+		t.Fatalf("expected the default project, got %q", task.ProjectName())
+	}
+
+	if err := task.SetProject("api"); err != nil || task.ProjectName() != "api" {
+		t.Fatalf("expected project api, got %q, %v", task.ProjectName(), err)
+	}
+	if err := task.SetProject(DefaultProject); err != nil || task.Project != "" {
+		t.Errorf("expected the default project to be stored empty, got %q, %v", task.Project, err)
+	}
+	if err := task.SetProject("two words"); err == nil {
+		t.Errorf("expected an invalid name to be refused")
+	}
+}
@@ -0,0 +1,366 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base period of a recurrence rule
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "daily"
+	FreqWeekly  Frequency = "weekly"
+	FreqMonthly Frequency = "monthly"
+	FreqYearly  Frequency = "yearly"
+)
+
+// Recurrence is a subset of RFC 5545 RRULE: FREQ, INTERVAL, BYDAY (weekly), BYMONTHDAY (monthly) and UNTIL.
+// It is stored on the task in its canonical String form.
+type Recurrence struct {
+	Freq       Frequency
+	Interval   int            // every Interval periods, at least 1
+	ByDay      []time.Weekday // weekly: the days of the week, Monday first
+	ByMonthDay int            // monthly: day of month, -1 for the last day; 0 keeps the day of the previous occurrence
+	Until      string         // YYYY-MM-DD, last date an occurrence may fall on
+}
+
+const recurrenceHint = "expected: daily|weekly|monthly|yearly or an RRULE like FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"
+
+// ParseRecurrence parses a frequency name (daily, weekly, monthly, yearly) or an RRULE,
+// optionally prefixed with "RRULE:".
+func ParseRecurrence(input string) (Recurrence, error) {
+	s := strings.TrimSpace(input)
+	if f, ok := parseFrequency(s); ok {
+		return Recurrence{Freq: f, Interval: 1}, nil
+	}
+
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("invalid recurrence %q (%s)", input, recurrenceHint)}
+		}
+
+		switch key {
+		case "FREQ":
+			f, ok := parseFrequency(value)
+			if !ok {
+				return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("unsupported FREQ %q (expected: DAILY|WEEKLY|MONTHLY|YEARLY)", value)}
+			}
+			r.Freq = f
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("invalid INTERVAL %q (expected a positive number)", value)}
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := rruleWeekday(d)
+				if !ok {
+					return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("invalid BYDAY %q (expected: MO,TU,WE,TH,FR,SA,SU)", d)}
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n == 0 || n < -1 || n > 31 {
+				return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("invalid BYMONTHDAY %q (expected 1-31 or -1)", value)}
+			}
+			r.ByMonthDay = n
+		case "UNTIL":
+			// Date-times are accepted; only their date matters
+			d, err := time.Parse("20060102", value[:min(8, len(value))])
+			if err != nil {
+				if d, err = time.Parse(DateLayout, value); err != nil {
+					return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("invalid UNTIL %q (expected: YYYYMMDD)", value)}
+				}
+			}
+			r.Until = d.Format(DateLayout)
+		default:
+			return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("unsupported RRULE part %q (supported: FREQ, INTERVAL, BYDAY, BYMONTHDAY, UNTIL)", key)}
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return Recurrence{}, &ValidationError{Msg: fmt.Sprintf("invalid recurrence %q: FREQ is required", input)}
+	case len(r.ByDay) > 0 && r.Freq != FreqWeekly:
+		return Recurrence{}, &ValidationError{Msg: "BYDAY is only supported with FREQ=WEEKLY"}
+	case r.ByMonthDay != 0 && r.Freq != FreqMonthly:
+		return Recurrence{}, &ValidationError{Msg: "BYMONTHDAY is only supported with FREQ=MONTHLY"}
+	}
+
+	r.ByDay = uniqueWeekdays(r.ByDay)
+	return r, nil
+}
+
+// String renders the canonical form: the plain frequency name when nothing else is set, an RRULE otherwise
+func (r Recurrence) String() string {
+	if r.Interval <= 1 && len(r.ByDay) == 0 && r.ByMonthDay == 0 && r.Until == "" {
+		return string(r.Freq)
+	}
+
+	parts := []string{"FREQ=" + strings.ToUpper(string(r.Freq))}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = strings.ToUpper(wd.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if r.Until != "" {
+		parts = append(parts, "UNTIL="+strings.ReplaceAll(r.Until, "-", ""))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence strictly after the day of after.
+// ok is false once the series has passed Until.
+// Months without the requested day (the 31st, Feb 29) fall back to their last day.
+func (r Recurrence) Next(after time.Time) (next time.Time, ok bool) {
+	return r.next(after, 0)
+}
+
+// next is Next for a series anchored on a day of the month (0 for none): when after is that day
+// clamped to the end of a shorter month, monthly and yearly rules return to the anchor day.
+func (r Recurrence) next(after time.Time, anchorDay int) (next time.Time, ok bool) {
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+	interval := max(r.Interval, 1)
+
+	switch r.Freq {
+	case FreqDaily:
+		next = day.AddDate(0, 0, interval)
+	case FreqWeekly:
+		next = r.nextWeekly(day, interval)
+	case FreqMonthly:
+		next = r.nextMonthly(day, interval, anchorDay)
+	default:
+		next = dayOfMonth(day.Year()+interval, day.Month(), keptDay(day, anchorDay))
+	}
+
+	if r.Until != "" && next.Format(DateLayout) > r.Until {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r Recurrence) nextWeekly(day time.Time, interval int) time.Time {
+	if len(r.ByDay) == 0 {
+		return day.AddDate(0, 0, 7*interval)
+	}
+
+	// Weeks start on Monday; only every interval-th week counted from the week of day is eligible
+	start := WeekStart(day)
+	for d := day.AddDate(0, 0, 1); ; d = d.AddDate(0, 0, 1) {
+		weeks := int(WeekStart(d).Sub(start).Hours()/24) / 7
+		if weeks%interval == 0 && containsWeekday(r.ByDay, d.Weekday()) {
+			return d
+		}
+	}
+}
+
+func (r Recurrence) nextMonthly(day time.Time, interval, anchorDay int) time.Time {
+	if r.ByMonthDay == 0 {
+		return dayOfMonth(day.Year(), day.Month()+time.Month(interval), keptDay(day, anchorDay))
+	}
+
+	// The month of day itself counts when the requested day is still ahead
+	for k := 0; ; k += interval {
+		d := dayOfMonth(day.Year(), day.Month()+time.Month(k), r.ByMonthDay)
+		if d.After(day) {
+			return d
+		}
+	}
+}
+
+// keepsDay reports whether the rule repeats the day of the month of the previous occurrence,
+// which a shorter month may have clamped.
+func (r Recurrence) keepsDay() bool {
+	return r.Freq == FreqYearly || (r.Freq == FreqMonthly && r.ByMonthDay == 0)
+}
+
+// keptDay is the day of the month that the occurrence after day falls on: the day of day itself,
+// unless day is anchorDay clamped to the end of a shorter month. An occurrence moved to another day stays there.
+func keptDay(day time.Time, anchorDay int) int {
+	if anchorDay > day.Day() && day.AddDate(0, 0, 1).Day() == 1 {
+		return anchorDay
+	}
+	return day.Day()
+}
+
+// dayOfMonth returns the given day of a month, clamped to its last day; -1 is the last day.
+// month may overflow into following years.
+func dayOfMonth(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	if day == -1 || day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// WeekStart returns midnight of the Monday of the week containing t, in the location of t.
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+func parseFrequency(s string) (Frequency, bool) {
+	switch f := Frequency(strings.ToLower(s)); f {
+	case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+		return f, true
+	}
+	return "", false
+}
+
+func rruleWeekday(s string) (time.Weekday, bool) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.EqualFold(s, wd.String()[:2]) {
+			return wd, true
+		}
+	}
+	return 0, false
+}
+
+// uniqueWeekdays sorts days Monday first and drops duplicates
+func uniqueWeekdays(days []time.Weekday) []time.Weekday {
+	sort.Slice(days, func(i, j int) bool { return (days[i]+6)%7 < (days[j]+6)%7 })
+
+	var res []time.Weekday
+	for i, d := range days {
+		if i == 0 || d != days[i-1] {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func containsWeekday(days []time.Weekday, wd time.Weekday) bool {
+	for _, d := range days {
+		if d == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRecurrence makes the task repeat according to rule (see ParseRecurrence); an empty rule stops it
+func (t *Task) SetRecurrence(rule string) error {
+	var canonical string
+	if rule != "" {
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			return err
+		}
+		canonical = r.String()
+	}
+	if t.Recurrence == canonical {
+		return nil
+	}
+
+	t.Recurrence = canonical
+	t.AnchorDay = 0 // a new rule counts from the next due date
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// NextDue computes the due date of the occurrence that follows this one.
+// It is counted from the due date, or from the day of now for tasks without one;
+// occurrences that already lie in the past are skipped. An empty result means the series has ended.
+// Monthly and yearly rules that a shorter month clamped (the 31st to Apr 30, Feb 29 to Feb 28)
+// return to AnchorDay, the day the series started on, as soon as the month has it again.
+func (t *Task) NextDue(now time.Time) (string, error) {
+	r, err := ParseRecurrence(t.Recurrence)
+	if err != nil {
+		return "", err
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	base := today
+	if t.Due != "" {
+		if base, err = time.Parse(DateLayout, t.Due); err != nil {
+			return "", &ValidationError{Msg: fmt.Sprintf("invalid due date %q (expected: YYYY-MM-DD)", t.Due)}
+		}
+	}
+
+	anchorDay := t.AnchorDay
+	if anchorDay == 0 {
+		anchorDay = base.Day()
+	}
+	next, ok := r.next(base, anchorDay)
+	for ok && next.Before(today) {
+		next, ok = r.next(next, anchorDay)
+	}
+	if !ok {
+		return "", nil
+	}
+
+	return next.Format(DateLayout), nil
+}
+
+// followUpAnchorDay is the AnchorDay of the occurrence that follows this one on due: the day the series started on,
+// for rules that keep the day of the month; 0 for the others.
+func (t *Task) followUpAnchorDay(due string) int {
+	r, err := ParseRecurrence(t.Recurrence)
+	if err != nil || !r.keepsDay() {
+		return 0
+	}
+	if t.AnchorDay != 0 {
+		return t.AnchorDay
+	}
+
+	from := due
+	if t.Due != "" {
+		from = t.Due
+	}
+	d, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return 0
+	}
+	return d.Day()
+}
+
+// NextOccurrence creates the follow-up of a recurring task: a fresh task in status (the initial one of the workflow)
+// with the given ID and due date that copies what describes the work and stays linked to the series through SeriesID.
+func (t *Task) NextOccurrence(id int, due string, status TaskStatus) (*Task, error) {
+	next, err := NewTask(id, t.Description, status)
+	if err != nil {
+		return nil, err
+	}
+
+	next.Priority = t.Priority
+	next.Tags = append([]string(nil), t.Tags...)
+	next.ParentID = t.ParentID
+	next.Project = t.Project
+	next.Estimate = t.Estimate
+	next.Assignees = append([]string(nil), t.Assignees...)
+	next.Fields = maps.Clone(t.Fields)
+	for _, item := range t.Checklist {
+		next.Checklist = append(next.Checklist, CheckItem{Text: item.Text})
+	}
+	next.Recurrence = t.Recurrence
+	next.AnchorDay = t.followUpAnchorDay(due)
+	next.SeriesID = t.SeriesID
+	if next.SeriesID == 0 {
+		next.SeriesID = t.ID
+	}
+	if err := next.SetDue(due); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence_Canonical(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"daily", "daily"},
+		{"Weekly", "weekly"},
+		{"FREQ=MONTHLY", "monthly"},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=1", "weekly"},
+		{"freq=weekly;byday=fr,mo,fr;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"FREQ=DAILY;UNTIL=20251231T235959Z", "FREQ=DAILY;UNTIL=20251231"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseRecurrence_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"", "fortnightly", "FREQ=HOURLY", "INTERVAL=2", "FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYDAY=MO", "FREQ=WEEKLY;BYDAY=XX", "FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32", "FREQ=DAILY;COUNT=3", "FREQ=DAILY;UNTIL=soon",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseRecurrence(input)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		rule  string
+		after string
+		want  string // "" when the series has ended
+	}{
+		{"daily", "2025-03-12", "2025-03-13"},
+		{"FREQ=DAILY;INTERVAL=3", "2025-03-30", "2025-04-02"},
+		{"weekly", "2025-03-12", "2025-03-19"},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", "2025-03-12", "2025-03-14"},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", "2025-03-14", "2025-03-17"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "2025-03-14", "2025-03-24"},
+		{"monthly", "2025-01-31", "2025-02-28"},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", "2025-03-12", "2025-03-15"},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", "2025-03-15", "2025-04-15"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2025-01-31", "2025-02-28"},
+		{"FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", "2025-11-20", "2026-02-01"},
+		{"yearly", "2024-02-29", "2025-02-28"},
+		{"FREQ=DAILY;UNTIL=20250313", "2025-03-12", "2025-03-13"},
+		{"FREQ=DAILY;UNTIL=20250313", "2025-03-13", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" after "+tt.after, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			after, _ := time.Parse(DateLayout, tt.after)
+
+			next, ok := r.Next(after)
+			got := ""
+			if ok {
+				got = next.Format(DateLayout)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextDue(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		due  string
+		want string
+	}{
+		{"from due date", "weekly", "2025-03-14", "2025-03-21"},
+		{"without due date counts from today", "daily", "", "2025-03-13"},
+		{"skips missed occurrences", "weekly", "2025-02-20", "2025-03-13"},
+		{"series ended", "FREQ=DAILY;UNTIL=20250301", "2025-02-27", ""},
+		{"monthly skips missed occurrences on the same day", "monthly", "2024-12-31", "2025-03-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := Task{ID: 1, Recurrence: tt.rule, Due: tt.due}
+			got, err := task.NextDue(refNow)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	task, _ := NewTask(4, "Rotate keys", StatusTodo)
+	_ = task.SetPriority(PriorityHigh)
+	_ = task.UpdateTags([]string{"infra"}, nil)
+	_ = task.SetRecurrence("weekly")
+	_ = task.AddBlocker(1)
+	_ = task.SetProject("ops")
+	_ = task.Assign("alice")
+	_ = task.AddCheckItem("Revoke old keys")
+	_, _ = task.ToggleCheckItem(1)
+	_ = task.SetField(newFields(t, []FieldDef{{Name: "ticket"}}), "ticket", "OPS-7")
+	_ = task.MarkDone(DefaultWorkflow())
+
+	next, err := task.NextOccurrence(9, "2025-03-19", StatusTodo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if next.ID != 9 || next.Status != StatusTodo || next.Due != "2025-03-19" {
+		t.Errorf("unexpected occurrence %+v", next)
+	}
+	if next.Priority != PriorityHigh || len(next.Tags) != 1 || next.Recurrence != "weekly" || next.Project != "ops" || !next.IsAssignedTo("alice") {
+		t.Errorf("expected attributes to be copied, got %+v", next)
+	}
+	if next.Fields["ticket"] != "OPS-7" {
+		t.Errorf("expected the custom fields to be copied, got %v", next.Fields)
+	}
+	next.Fields["ticket"] = "OPS-8"
+	if task.Fields["ticket"] != "OPS-7" {
+		t.Errorf("the occurrence should not share its fields with the task, got %v", task.Fields)
+	}
+	if len(next.Checklist) != 1 || next.Checklist[0].Done {
+		t.Errorf("expected the checklist to start unchecked, got %+v", next.Checklist)
+	}
+	if next.SeriesID != 4 || next.BlockedBy != nil {
+		t.Errorf("expected series 4 without blockers, got %+v", next)
+	}
+
+	following, _ := next.NextOccurrence(10, "2025-03-26", StatusTodo)
+	if following.SeriesID != 4 {
+		t.Errorf("series should stay anchored at 4, got %d", following.SeriesID)
+	}
+}
+
+// series completes the occurrences of task one after the other and returns their due dates
+func series(t *testing.T, task Task, n int) ([]string, Task) {
+	t.Helper()
+
+	var dues []string
+	for i := 1; i <= n; i++ {
+		due, err := task.NextDue(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		next, err := task.NextOccurrence(task.ID+1, due, StatusTodo)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dues = append(dues, next.Due)
+		task = *next
+	}
+	return dues, task
+}
+
+func TestNextOccurrence_MonthlyKeepsTheDayOfTheMonth(t *testing.T) {
+	dues, last := series(t, Task{ID: 1, Description: "Pay rent", Recurrence: "monthly", Due: "2025-01-31"}, 4)
+
+	want := []string{"2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31"}
+	if !reflect.DeepEqual(dues, want) {
+		t.Errorf("expected %v, got %v", want, dues)
+	}
+	if last.Recurrence != "monthly" || last.AnchorDay != 31 {
+		t.Errorf("expected the rule unchanged and the series anchored on the 31st, got %q, %d", last.Recurrence, last.AnchorDay)
+	}
+
+	// an occurrence moved to another day stays on it
+	last.Due = "2025-06-15"
+	if dues, _ = series(t, last, 1); dues[0] != "2025-07-15" {
+		t.Errorf("expected the moved occurrence to keep its day, got %v", dues)
+	}
+
+	if err := last.SetRecurrence("FREQ=MONTHLY;INTERVAL=2"); err != nil || last.AnchorDay != 0 {
+		t.Errorf("expected a new rule to drop the anchor, got %d, %v", last.AnchorDay, err)
+	}
+	if _, weekly := series(t, Task{ID: 1, Description: "Standup", Recurrence: "weekly", Due: "2025-01-31"}, 1); weekly.AnchorDay != 0 {
+		t.Errorf("expected no anchor for a weekly rule, got %d", weekly.AnchorDay)
+	}
+}
+
+func TestNextOccurrence_YearlyKeepsFebruary29(t *testing.T) {
+	dues, _ := series(t, Task{ID: 1, Description: "Leap day party", Recurrence: "yearly", Due: "2024-02-29"}, 4)
+
+	want := []string{"2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"}
+	if !reflect.DeepEqual(dues, want) {
+		t.Errorf("expected %v, got %v", want, dues)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*3600)
+	tests := []struct {
+		in   time.Time
+		want time.Time
+	}{
+		{refNow, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},                                       // Wednesday
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)}, // Monday itself
+		{time.Date(2025, 3, 16, 23, 30, 0, 0, loc), time.Date(2025, 3, 10, 0, 0, 0, 0, loc)},         // Sunday, in its own location
+	}
+
+	for _, tt := range tests {
+		if got := WeekStart(tt.in); !got.Equal(tt.want) || got.Location() != tt.in.Location() {
+			t.Errorf("%s: expected %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
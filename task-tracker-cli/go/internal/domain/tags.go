@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxTagLength keeps tags short enough to list inline
+const maxTagLength = 32
+
+// NormalizeTag lowercases and trims a tag and checks its characters.
+// A leading "#" is accepted and dropped; tags must start with a letter or digit
+// and may contain letters, digits and - _ . / :
+func NormalizeTag(s string) (string, error) {
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "#"))
+
+	if tag == "" {
+		return "", &ValidationError{Msg: "tag cannot be empty"}
+	}
+	if len(tag) > maxTagLength {
+		return "", &ValidationError{Msg: fmt.Sprintf("tag %q is longer than %d characters", tag, maxTagLength)}
+	}
+
+	for i, r := range tag {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+		case i > 0 && strings.ContainsRune("-_./:", r):
+		default:
+			return "", &ValidationError{Msg: fmt.Sprintf("invalid tag %q (use letters, digits and - _ . / :)", s)}
+		}
+	}
+
+	return tag, nil
+}
+
+// HasTag reports whether the task carries the (normalized) tag
+func (t *Task) HasTag(tag string) bool {
+	i := sort.SearchStrings(t.Tags, tag)
+	return i < len(t.Tags) && t.Tags[i] == tag
+}
+
+// UpdateTags adds and removes tags, keeping the set normalized, unique and sorted.
+// Nothing changes if any tag is invalid.
+func (t *Task) UpdateTags(add, remove []string) error {
+	set := make(map[string]bool, len(t.Tags)+len(add))
+	for _, tag := range t.Tags {
+		set[tag] = true
+	}
+
+	for _, raw := range add {
+		tag, err := NormalizeTag(raw)
+		if err != nil {
+			return err
+		}
+		set[tag] = true
+	}
+	for _, raw := range remove {
+		tag, err := NormalizeTag(raw)
+		if err != nil {
+			return err
+		}
+		delete(set, tag)
+	}
+
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	if equalStrings(tags, t.Tags) {
+		return nil
+	}
+
+	if len(tags) == 0 {
+		tags = nil
+	}
+	t.Tags = tags
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
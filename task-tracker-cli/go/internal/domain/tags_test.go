@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"backend", "backend"},
+		{"  Infra ", "infra"},
+		{"#Docs", "docs"},
+		{"team/api", "team/api"},
+		{"v1.2", "v1.2"},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeTag_Invalid_ShouldFail(t *testing.T) {
+	for _, input := range []string{"", "  ", "#", "-dash", "!not", "two words", "a,b", "waaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaytoolong"} {
+		_, err := NormalizeTag(input)
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%q: expected ValidationError, got %T: %v", input, err, err)
+		}
+	}
+}
+
+func TestUpdateTags(t *testing.T) {
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+
+	if err := task.UpdateTags([]string{"Infra", "backend", "infra"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"backend", "infra"}; !reflect.DeepEqual(task.Tags, want) {
+		t.Errorf("expected %v, got %v", want, task.Tags)
+	}
+
+	if err := task.UpdateTags([]string{"docs"}, []string{"BACKEND"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"docs", "infra"}; !reflect.DeepEqual(task.Tags, want) {
+		t.Errorf("expected %v, got %v", want, task.Tags)
+	}
+	if !task.HasTag("docs") || task.HasTag("backend") {
+		t.Errorf("HasTag disagrees with %v", task.Tags)
+	}
+
+	if err := task.UpdateTags(nil, []string{"docs", "infra"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Tags != nil {
+		t.Errorf("expected no tags, got %v", task.Tags)
+	}
+}
+
+func TestUpdateTags_InvalidTagChangesNothing(t *testing.T) {
+	task, _ := NewTask(1, "Deploy", StatusTodo)
+	_ = task.UpdateTags([]string{"infra"}, nil)
+
+	if err := task.UpdateTags([]string{"ok", "not ok"}, nil); err == nil {
+		t.Fatalf("expected error for invalid tag")
+	}
+	if !reflect.DeepEqual(task.Tags, []string{"infra"}) {
+		t.Errorf("tags should be unchanged, got %v", task.Tags)
+	}
+}
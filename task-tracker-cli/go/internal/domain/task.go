@@ -11,16 +11,48 @@ type TaskStatus string
 const (
 	StatusTodo       TaskStatus = "todo"
 	StatusInProgress TaskStatus = "in-progress"
+	StatusBlocked    TaskStatus = "blocked"
+	StatusInReview   TaskStatus = "in-review"
 	StatusDone       TaskStatus = "done"
+	StatusCancelled  TaskStatus = "cancelled"
+)
+
+// Priority expresses how important a task is
+type Priority string
+
+const (
+	PriorityLow      Priority = "low"
+	PriorityMedium   Priority = "medium"
+	PriorityHigh     Priority = "high"
+	PriorityCritical Priority = "critical"
 )
 
 // Task is aggregate root
+// Revision is bumped by the store on every persisted mutation and used for optimistic concurrency.
 type Task struct {
-	ID          int        `json:"id"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
-	CreatedAt   string     `json:"createdAt"`
-	UpdatedAt   string     `json:"updatedAt"`
+	ID          int               `json:"id"`
+	Description string            `json:"description"`
+	Status      TaskStatus        `json:"status"`
+	CreatedAt   string            `json:"createdAt"`
+	UpdatedAt   string            `json:"updatedAt"`
+	Revision    int               `json:"revision"`
+	Priority    Priority          `json:"priority"`
+	Due         string            `json:"due,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	ParentID    int               `json:"parentId,omitempty"`
+	BlockedBy   []int             `json:"blockedBy,omitempty"` // sorted IDs of tasks that must be done first
+	Recurrence  string            `json:"recurrence,omitempty"`
+	SeriesID    int               `json:"seriesId,omitempty"`  // first task of the recurring series this occurrence belongs to
+	AnchorDay   int               `json:"anchorDay,omitempty"` // day of the month the series started on; see NextDue
+	TimeEntries []TimeEntry       `json:"timeEntries,omitempty"`
+	History     []Change          `json:"history,omitempty"`   // append-only, oldest first
+	DeletedAt   string            `json:"deletedAt,omitempty"` // RFC3339 in UTC while the task is in the trash
+	Comments    []Comment         `json:"comments,omitempty"`  // oldest first
+	Project     string            `json:"project,omitempty"`   // empty for DefaultProject
+	Fields      map[string]string `json:"fields,omitempty"`    // custom fields in canonical form; see FieldSet
+	Checklist   []CheckItem       `json:"checklist,omitempty"` // in order; items are numbered from 1
+	Estimate    string            `json:"estimate,omitempty"`  // canonical Estimate: points like "3" or a duration like "4h"
+	Assignees   []string          `json:"assignees,omitempty"` // sorted user names
 }
 
 // Domain errors
@@ -36,17 +68,57 @@ type ValidationError struct{ Msg string }
 
 func (e *ValidationError) Error() string { return e.Msg }
 
-// ParseStatus parses a string into a TaskStatus
-func ParseStatus(s string) (TaskStatus, error) {
+// Conflict Error
+// Returned when a task was changed by someone else since it was read
+type ConflictError struct{ Msg string }
+
+func (e *ConflictError) Error() string { return e.Msg }
+
+// NewConflictError reports that task id is at revision actual while the caller expected revision expected.
+func NewConflictError(id, expected, actual int) *ConflictError {
+	return &ConflictError{Msg: fmt.Sprintf("task %d was modified concurrently (revision %d, expected %d)", id, actual, expected)}
+}
+
+// ParseStatus parses a string into one of the statuses of workflow w
+func ParseStatus(w Workflow, s string) (TaskStatus, error) {
+	all := w.StatusNames()
+	for _, st := range all {
+		if s == string(st) {
+			return st, nil
+		}
+	}
+
+	return "", &ValidationError{Msg: fmt.Sprintf("invalid status %q (expected: %s)", s, strings.ReplaceAll(joinStatuses(all), ", ", "|"))}
+}
+
+// ParsePriority parses a string into a Priority
+func ParsePriority(s string) (Priority, error) {
 	switch s {
-	case string(StatusTodo):
-		return StatusTodo, nil
-	case string(StatusInProgress):
-		return StatusInProgress, nil
-	case string(StatusDone):
-		return StatusDone, nil
+	case string(PriorityLow):
+		return PriorityLow, nil
+	case string(PriorityMedium):
+		return PriorityMedium, nil
+	case string(PriorityHigh):
+		return PriorityHigh, nil
+	case string(PriorityCritical):
+		return PriorityCritical, nil
+	default:
+		return "", &ValidationError{Msg: fmt.Sprintf("invalid priority %q (expected: low|medium|high|critical)", s)}
+	}
+}
+
+// Rank orders priorities from low (1) to critical (4).
+// Tasks stored before priorities existed have none and rank as medium.
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityLow:
+		return 1
+	case PriorityHigh:
+		return 3
+	case PriorityCritical:
+		return 4
 	default:
-		return "", &ValidationError{Msg: fmt.Sprintf("invalid status %q (expected: todo|in-progress|done)", s)}
+		return 2
 	}
 }
 
@@ -56,8 +128,8 @@ func NowIso() string {
 }
 
 // NewTask is Task aggregate constructor.
-// It enforces initial invariants
-func NewTask(id int, desc string) (*Task, error) {
+// It enforces initial invariants; status is the initial status of the workflow in use.
+func NewTask(id int, desc string, status TaskStatus) (*Task, error) {
 	if id <= 0 {
 		return nil, &ValidationError{Msg: "id must be positive!"}
 	}
@@ -70,12 +142,85 @@ func NewTask(id int, desc string) (*Task, error) {
 	return &Task{
 		ID:          id,
 		Description: desc,
-		Status:      StatusTodo,
+		Status:      status,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Revision:    1,
+		Priority:    PriorityMedium,
 	}, nil
 }
 
+// Validate checks the invariants of a task edited outside the aggregate methods
+// against the workflow and the custom fields in use
+func (t *Task) Validate(w Workflow, fields FieldSet) error {
+	if t.ID <= 0 {
+		return &ValidationError{Msg: "id must be positive!"}
+	}
+	if strings.TrimSpace(t.Description) == "" {
+		return &ValidationError{Msg: "description cannot be empty"}
+	}
+	if _, err := ParseStatus(w, string(t.Status)); err != nil {
+		return err
+	}
+	if t.Priority != "" {
+		if _, err := ParsePriority(string(t.Priority)); err != nil {
+			return err
+		}
+	}
+	if t.Due != "" {
+		if _, err := time.Parse(DateLayout, t.Due); err != nil {
+			return &ValidationError{Msg: fmt.Sprintf("invalid due date %q (expected: YYYY-MM-DD)", t.Due)}
+		}
+	}
+	for _, tag := range t.Tags {
+		if norm, err := NormalizeTag(tag); err != nil || norm != tag {
+			return &ValidationError{Msg: fmt.Sprintf("tag %q is not normalized", tag)}
+		}
+	}
+	for i, user := range t.Assignees {
+		if name, err := ParseAssignee(user); err != nil || name != user || (i > 0 && user <= t.Assignees[i-1]) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid assignees %q (expected sorted, unique user names)", t.Assignees)}
+		}
+	}
+	if t.Recurrence != "" {
+		if _, err := ParseRecurrence(t.Recurrence); err != nil {
+			return err
+		}
+	}
+	if t.Estimate != "" {
+		if e, err := ParseEstimate(t.Estimate); err != nil || e.String() != t.Estimate {
+			return &ValidationError{Msg: fmt.Sprintf("invalid estimate %q (expected points like 3 or a duration like 4h)", t.Estimate)}
+		}
+	}
+	running := 0
+	for _, e := range t.TimeEntries {
+		if _, _, err := e.Span(time.Now()); err != nil {
+			return err
+		}
+		if e.Running() {
+			running++
+		}
+	}
+	if running > 1 {
+		return &ValidationError{Msg: fmt.Sprintf("task %d has %d running timers", t.ID, running)}
+	}
+	if err := fields.validate(t); err != nil {
+		return err
+	}
+	for i, item := range t.Checklist {
+		if strings.TrimSpace(item.Text) == "" {
+			return &ValidationError{Msg: fmt.Sprintf("checklist item %d is empty", i+1)}
+		}
+	}
+	for i, b := range t.BlockedBy {
+		if b <= 0 || b == t.ID || (i > 0 && b <= t.BlockedBy[i-1]) {
+			return &ValidationError{Msg: fmt.Sprintf("invalid blockers %v (expected sorted IDs of other tasks)", t.BlockedBy)}
+		}
+	}
+
+	return nil
+}
+
 // UpdateDescription changes the task description and refreshes its updatedAt
 func (t *Task) UpdateDescription(desc string) error {
 	if strings.TrimSpace(desc) == "" {
@@ -87,24 +232,27 @@ func (t *Task) UpdateDescription(desc string) error {
 	return nil
 }
 
-// MarkInProgress transition task in progress if allowed
-func (t *Task) MarkInProgress() error {
-	if t.Status == StatusDone {
-		return &ValidationError{Msg: "cannot mark done task as in progress"}
+// SetPriority changes the task priority and refreshes its updatedAt
+func (t *Task) SetPriority(p Priority) error {
+	if _, err := ParsePriority(string(p)); err != nil {
+		return err
+	}
+	if t.Priority == p {
+		return nil
 	}
 
-	t.Status = StatusInProgress
+	t.Priority = p
 	t.UpdatedAt = NowIso()
 	return nil
 }
 
-// MarkDone transition the task to done
-func (t *Task) MarkDone() error {
-	if t.Status == StatusDone {
-		return nil // idempotent
-	}
+// MarkInProgress transition task in progress if w allows it.
+// openBlockers are the blockers that are not closed yet (see OpenBlockers); pass nil to override them.
+func (t *Task) MarkInProgress(w Workflow, openBlockers []int) error {
+	return t.applyNamed(w, TransitionStart, openBlockers)
+}
 
-	t.Status = StatusDone
-	t.UpdatedAt = NowIso()
-	return nil
+// MarkDone transition the task to done
+func (t *Task) MarkDone(w Workflow) error {
+	return t.applyNamed(w, TransitionDone, nil)
 }
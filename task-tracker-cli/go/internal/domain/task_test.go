@@ -6,7 +6,7 @@ import (
 )
 
 func TestNewTask(t *testing.T) {
-	task, err := NewTask(1, "Buy tomato")
+	task, err := NewTask(1, "Buy tomato", StatusTodo)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -23,7 +23,7 @@ func TestNewTask(t *testing.T) {
 }
 
 func TestUpdateDescription(t *testing.T) {
-	task, _ := NewTask(1, "Buy tomato")
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
 	err := task.UpdateDescription("Buy 1kg tomato")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -34,7 +34,7 @@ func TestUpdateDescription(t *testing.T) {
 }
 
 func TestUpdateDescription_EmptyDescription_ShouldFail(t *testing.T) {
-	task, _ := NewTask(1, "Buy tomato")
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
 	originalDesc := task.Description
 
 	err := task.UpdateDescription("")
@@ -54,8 +54,8 @@ func TestUpdateDescription_EmptyDescription_ShouldFail(t *testing.T) {
 }
 
 func TestMarkInProgress(t *testing.T) {
-	task, _ := NewTask(1, "Buy tomato")
-	err := task.MarkInProgress()
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
+	err := task.MarkInProgress(DefaultWorkflow(), nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -65,10 +65,10 @@ func TestMarkInProgress(t *testing.T) {
 }
 
 func TestMarkInProgress_FromDone_ShouldFail(t *testing.T) {
-	task, _ := NewTask(1, "Buy tomato")
-	_ = task.MarkDone()
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
+	_ = task.MarkDone(DefaultWorkflow())
 
-	err := task.MarkInProgress()
+	err := task.MarkInProgress(DefaultWorkflow(), nil)
 	if err == nil {
 		t.Fatalf("expected error when marking done task as in-progress")
 	}
@@ -85,9 +85,9 @@ func TestMarkInProgress_FromDone_ShouldFail(t *testing.T) {
 }
 
 func TestMarkDoneIsIdempotent(t *testing.T) {
-	task, _ := NewTask(1, "Buy tomato")
-	_ = task.MarkDone()
-	err := task.MarkDone() // second call
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
+	_ = task.MarkDone(DefaultWorkflow())
+	err := task.MarkDone(DefaultWorkflow()) // second call
 
 	if err != nil {
 		t.Fatalf("mark-done should be idempotent")
@@ -109,7 +109,7 @@ func TestNewTask_InvalidID_ShouldFail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			task, err := NewTask(tt.id, "Valid description")
+			task, err := NewTask(tt.id, "Valid description", StatusTodo)
 			if err == nil {
 				t.Fatalf("expected error for ID %d", tt.id)
 			}
@@ -139,7 +139,7 @@ func TestNewTask_EmptyDescription_ShouldFail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			task, err := NewTask(1, tt.description)
+			task, err := NewTask(1, tt.description, StatusTodo)
 			if err == nil {
 				t.Fatalf("expected error for description %q", tt.description)
 			}
@@ -155,3 +155,73 @@ func TestNewTask_EmptyDescription_ShouldFail(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTask_StartsAtRevisionOne(t *testing.T) {
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
+	if task.Revision != 1 {
+		t.Errorf("expected revision 1, got %d", task.Revision)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		task Task
+		ok   bool
+	}{
+		{"valid", Task{ID: 1, Description: "A", Status: StatusTodo}, true},
+		{"zero ID", Task{ID: 0, Description: "A", Status: StatusTodo}, false},
+		{"empty description", Task{ID: 1, Description: " ", Status: StatusTodo}, false},
+		{"unknown status", Task{ID: 1, Description: "A", Status: "later"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.task.Validate(DefaultWorkflow(), FieldSet{})
+			if tt.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.ok {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for _, p := range []Priority{PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical} {
+		got, err := ParsePriority(string(p))
+		if err != nil || got != p {
+			t.Errorf("ParsePriority(%q) = %q, %v", p, got, err)
+		}
+	}
+
+	_, err := ParsePriority("urgent")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+}
+
+func TestPriorityRank(t *testing.T) {
+	if !(PriorityCritical.Rank() > PriorityHigh.Rank() && PriorityHigh.Rank() > PriorityMedium.Rank() && PriorityMedium.Rank() > PriorityLow.Rank()) {
+		t.Errorf("priorities are not ranked low < medium < high < critical")
+	}
+	if Priority("").Rank() != PriorityMedium.Rank() {
+		t.Errorf("missing priority should rank as medium")
+	}
+}
+
+func TestSetPriority_Invalid_ShouldFail(t *testing.T) {
+	task, _ := NewTask(1, "Buy tomato", StatusTodo)
+
+	if err := task.SetPriority("urgent"); err == nil {
+		t.Fatalf("expected error for invalid priority")
+	}
+	if task.Priority != PriorityMedium {
+		t.Errorf("priority should remain medium, got %q", task.Priority)
+	}
+}
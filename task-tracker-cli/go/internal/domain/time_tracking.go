@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeEntry is a span of work on a task, either timed with start/stop or logged manually.
+// Timestamps are RFC3339 in UTC like CreatedAt; End is empty while the timer is running.
+type TimeEntry struct {
+	Start  string `json:"start"`
+	End    string `json:"end,omitempty"`
+	Manual bool   `json:"manual,omitempty"`
+}
+
+// Running reports whether the entry is an active timer
+func (e TimeEntry) Running() bool { return e.End == "" }
+
+// Span returns the start and end of the entry; a running timer ends at now
+func (e TimeEntry) Span(now time.Time) (start, end time.Time, err error) {
+	if start, err = time.Parse(time.RFC3339, e.Start); err != nil {
+		return start, end, &ValidationError{Msg: fmt.Sprintf("invalid time entry start %q", e.Start)}
+	}
+	if e.Running() {
+		return start, now, nil
+	}
+	if end, err = time.Parse(time.RFC3339, e.End); err != nil {
+		return start, end, &ValidationError{Msg: fmt.Sprintf("invalid time entry end %q", e.End)}
+	}
+	return start, end, nil
+}
+
+// ParseWorkDuration parses logged work such as 1h30m, 45m or 1.5h
+func ParseWorkDuration(input string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.ToLower(strings.TrimSpace(input)))
+	if err != nil || d <= 0 {
+		return 0, &ValidationError{Msg: fmt.Sprintf("invalid duration %q (expected e.g. 1h30m, 45m, 1.5h)", input)}
+	}
+	return d.Round(time.Second), nil
+}
+
+// ActiveTimer returns the running time entry of the task, if any
+func (t *Task) ActiveTimer() (TimeEntry, bool) {
+	for _, e := range t.TimeEntries {
+		if e.Running() {
+			return e, true
+		}
+	}
+	return TimeEntry{}, false
+}
+
+// StartTimer begins timing work on the task at now; a task closed in workflow w cannot be timed
+func (t *Task) StartTimer(w Workflow, now time.Time) error {
+	if w.IsClosed(t.Status) {
+		return &ValidationError{Msg: fmt.Sprintf("cannot start a timer on a %s task", t.Status)}
+	}
+	if _, running := t.ActiveTimer(); running {
+		return &ValidationError{Msg: fmt.Sprintf("timer is already running on task %d", t.ID)}
+	}
+
+	t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: now.UTC().Format(time.RFC3339)})
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// StopTimer ends the running timer at now and returns how long it ran
+func (t *Task) StopTimer(now time.Time) (time.Duration, error) {
+	for i, e := range t.TimeEntries {
+		if !e.Running() {
+			continue
+		}
+
+		start, _, err := e.Span(now)
+		if err != nil {
+			return 0, err
+		}
+		if now.Before(start) {
+			now = start // clock skew between invocations; never record a negative span
+		}
+
+		t.TimeEntries[i].End = now.UTC().Format(time.RFC3339)
+		t.UpdatedAt = NowIso()
+		return now.Sub(start), nil
+	}
+
+	return 0, &ValidationError{Msg: fmt.Sprintf("no timer is running on task %d", t.ID)}
+}
+
+// LogTime records d of work that ended at now
+func (t *Task) LogTime(d time.Duration, now time.Time) error {
+	if d <= 0 {
+		return &ValidationError{Msg: "logged time must be positive"}
+	}
+
+	t.TimeEntries = append(t.TimeEntries, TimeEntry{
+		Start:  now.Add(-d).UTC().Format(time.RFC3339),
+		End:    now.UTC().Format(time.RFC3339),
+		Manual: true,
+	})
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// TrackedTime sums every time entry of the task, counting a running timer up to now
+func (t *Task) TrackedTime(now time.Time) (time.Duration, error) {
+	var total time.Duration
+	for _, e := range t.TimeEntries {
+		start, end, err := e.Span(now)
+		if err != nil {
+			return 0, err
+		}
+		total += end.Sub(start)
+	}
+	return total, nil
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWorkDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		ok    bool
+	}{
+		{"1h30m", 90 * time.Minute, true},
+		{"45m", 45 * time.Minute, true},
+		{"1.5H", 90 * time.Minute, true},
+		{"0m", 0, false},
+		{"-1h", 0, false},
+		{"90", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseWorkDuration(tt.input)
+			if !tt.ok {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStartStopTimer(t *testing.T) {
+	task, _ := NewTask(1, "Write report", StatusTodo)
+
+	if err := task.StartTimer(DefaultWorkflow(), refNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := task.StartTimer(DefaultWorkflow(), refNow); err == nil {
+		t.Fatalf("expected error when starting a second timer")
+	}
+
+	tracked, _ := task.TrackedTime(refNow.Add(10 * time.Minute))
+	if tracked != 10*time.Minute {
+		t.Errorf("expected running timer to count 10m, got %s", tracked)
+	}
+
+	elapsed, err := task.StopTimer(refNow.Add(25 * time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed != 25*time.Minute {
+		t.Errorf("expected 25m, got %s", elapsed)
+	}
+	if _, running := task.ActiveTimer(); running {
+		t.Errorf("timer should be stopped")
+	}
+
+	if _, err := task.StopTimer(refNow); err == nil {
+		t.Errorf("expected error when no timer is running")
+	}
+}
+
+func TestStartTimer_OnDoneTask_ShouldFail(t *testing.T) {
+	task, _ := NewTask(1, "Write report", StatusTodo)
+	_ = task.MarkDone(DefaultWorkflow())
+
+	err := task.StartTimer(DefaultWorkflow(), refNow)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+}
+
+func TestLogTime(t *testing.T) {
+	task, _ := NewTask(1, "Write report", StatusTodo)
+
+	if err := task.LogTime(90*time.Minute, refNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := task.TimeEntries[0]
+	if !e.Manual || e.End != "2025-03-12T15:04:00Z" || e.Start != "2025-03-12T13:34:00Z" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if tracked, _ := task.TrackedTime(refNow); tracked != 90*time.Minute {
+		t.Errorf("expected 1h30m tracked, got %s", tracked)
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IsTrashed reports whether the task was deleted and waits in the trash
+func (t *Task) IsTrashed() bool { return t.DeletedAt != "" }
+
+// Trash moves the task to the trash at now, stopping its timer
+func (t *Task) Trash(now time.Time) error {
+	if t.IsTrashed() {
+		return &ValidationError{Msg: fmt.Sprintf("task %d is already in the trash", t.ID)}
+	}
+	if _, running := t.ActiveTimer(); running {
+		if _, err := t.StopTimer(now); err != nil {
+			return err
+		}
+	}
+
+	t.DeletedAt = now.UTC().Format(time.RFC3339)
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// Restore takes the task back out of the trash
+func (t *Task) Restore() error {
+	if !t.IsTrashed() {
+		return &ValidationError{Msg: fmt.Sprintf("task %d is not in the trash", t.ID)}
+	}
+
+	t.DeletedAt = ""
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// TrashedBefore reports whether the task went to the trash before cutoff
+func (t *Task) TrashedBefore(cutoff time.Time) (bool, error) {
+	if !t.IsTrashed() {
+		return false, nil
+	}
+
+	deleted, err := time.Parse(time.RFC3339, t.DeletedAt)
+	if err != nil {
+		return false, &ValidationError{Msg: fmt.Sprintf("invalid deletion time %q of task %d", t.DeletedAt, t.ID)}
+	}
+	return deleted.Before(cutoff), nil
+}
+
+// ParseAge parses an age such as 30d, 2w or 12h; 0d means any age
+func ParseAge(input string) (time.Duration, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	invalid := &ValidationError{Msg: fmt.Sprintf("invalid age %q (expected e.g. 30d, 2w, 12h)", input)}
+	if len(s) < 2 {
+		return 0, invalid
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return 0, invalid
+	}
+	switch s[len(s)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, invalid
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30d", 30 * 24 * time.Hour, true},
+		{"2W", 14 * 24 * time.Hour, true},
+		{"12h", 12 * time.Hour, true},
+		{"0d", 0, true},
+		{"-1d", 0, false},
+		{"30", 0, false},
+		{"d", 0, false},
+		{"1m", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAge(tt.input)
+			if !tt.ok {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("expected %v, got %v, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestTrash(t *testing.T) {
+	task := Task{ID: 1, Description: "A", Status: StatusTodo}
+	if err := task.StartTimer(DefaultWorkflow(), refNow.Add(-time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := task.Trash(refNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !task.IsTrashed() || task.DeletedAt != "2025-03-12T15:04:00Z" {
+		t.Errorf("expected the task to be trashed at refNow, got %q", task.DeletedAt)
+	}
+	if _, running := task.ActiveTimer(); running {
+		t.Errorf("expected the timer to stop")
+	}
+	if err := task.Trash(refNow); err == nil {
+		t.Errorf("expected an error when trashing twice")
+	}
+
+	for _, tt := range []struct {
+		cutoff time.Time
+		want   bool
+	}{
+		{refNow.Add(time.Minute), true},
+		{refNow, false},
+	} {
+		if got, err := task.TrashedBefore(tt.cutoff); err != nil || got != tt.want {
+			t.Errorf("TrashedBefore(%v): expected %v, got %v, %v", tt.cutoff, tt.want, got, err)
+		}
+	}
+
+	if err := task.Restore(); err != nil || task.IsTrashed() {
+		t.Fatalf("expected the task to be restored, got %q, %v", task.DeletedAt, err)
+	}
+	if err := task.Restore(); err == nil {
+		t.Errorf("expected an error when restoring a live task")
+	}
+}
@@ -0,0 +1,322 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Transition is a named status change.
+// The transition table of the workflow is the single source of truth for which moves are allowed:
+// TaskService applies them by name or target status and the CLI offers one command per named transition.
+type Transition struct {
+	Name    string // also the CLI command; unnamed transitions are only reachable through "move"
+	From    []TaskStatus
+	To      TaskStatus
+	Message string // confirmation shown once the transition succeeded
+}
+
+// StatusCategory tells the rest of the domain what a status means, whatever it is called
+type StatusCategory string
+
+const (
+	CategoryOpen      StatusCategory = "open"      // not started yet
+	CategoryActive    StatusCategory = "active"    // being worked on; entering it requires closed blockers
+	CategoryDone      StatusCategory = "done"      // completed; entering it requires closed subtasks
+	CategoryCancelled StatusCategory = "cancelled" // dropped
+)
+
+// StatusDef declares one status of a workflow
+type StatusDef struct {
+	Name     TaskStatus
+	Category StatusCategory
+}
+
+// Workflow is a set of statuses and the transitions between them.
+// The first status is given to new tasks.
+type Workflow struct {
+	Statuses    []StatusDef
+	Transitions []Transition
+}
+
+// Names of the built-in transitions
+const (
+	TransitionStart  = "mark-in-progress"
+	TransitionBlock  = "mark-blocked"
+	TransitionReview = "mark-in-review"
+	TransitionDone   = "mark-done"
+	TransitionCancel = "cancel"
+	TransitionReopen = "reopen"
+)
+
+var defaultWorkflow = Workflow{
+	Statuses: []StatusDef{
+		{StatusTodo, CategoryOpen},
+		{StatusInProgress, CategoryActive},
+		{StatusBlocked, CategoryOpen},
+		{StatusInReview, CategoryActive},
+		{StatusDone, CategoryDone},
+		{StatusCancelled, CategoryCancelled},
+	},
+	Transitions: []Transition{
+		{TransitionStart, []TaskStatus{StatusTodo, StatusBlocked, StatusInReview}, StatusInProgress, "Task marked as in progress"},
+		{TransitionBlock, []TaskStatus{StatusTodo, StatusInProgress, StatusInReview}, StatusBlocked, "Task marked as blocked"},
+		{TransitionReview, []TaskStatus{StatusInProgress}, StatusInReview, "Task sent to review"},
+		{TransitionDone, []TaskStatus{StatusTodo, StatusInProgress, StatusBlocked, StatusInReview}, StatusDone, "Task marked as done"},
+		{TransitionCancel, []TaskStatus{StatusTodo, StatusInProgress, StatusBlocked, StatusInReview}, StatusCancelled, "Task cancelled"},
+		{TransitionReopen, []TaskStatus{StatusDone, StatusCancelled}, StatusTodo, "Task reopened"},
+	},
+}
+
+// DefaultWorkflow returns the built-in todo/in-progress/done workflow with blocked, in-review and cancelled
+func DefaultWorkflow() Workflow {
+	return defaultWorkflow.clone()
+}
+
+// NewWorkflow fills in the defaults of w and validates it.
+// Categories default to open, messages to "Task moved to <status>",
+// and a workflow without transitions allows moving between any two statuses.
+// reserved are names a transition may not take, such as the commands of the CLI.
+func NewWorkflow(w Workflow, reserved ...string) (Workflow, error) {
+	w = w.clone()
+	for i := range w.Statuses {
+		if w.Statuses[i].Category == "" {
+			w.Statuses[i].Category = CategoryOpen
+		}
+	}
+	if len(w.Transitions) == 0 {
+		w.Transitions = freeTransitions(w.Statuses)
+	}
+	for i := range w.Transitions {
+		if w.Transitions[i].Message == "" {
+			w.Transitions[i].Message = fmt.Sprintf("Task moved to %s", w.Transitions[i].To)
+		}
+	}
+
+	if err := w.Validate(reserved...); err != nil {
+		return Workflow{}, err
+	}
+	return w, nil
+}
+
+// Validate checks that statuses are unique with known categories and that transitions only reference them
+// and have unique names that are not reserved
+func (w Workflow) Validate(reserved ...string) error {
+	if len(w.Statuses) == 0 {
+		return &ValidationError{Msg: "workflow has no statuses"}
+	}
+
+	declared := map[TaskStatus]bool{}
+	for _, s := range w.Statuses {
+		switch {
+		case strings.TrimSpace(string(s.Name)) == "" || strings.ContainsAny(string(s.Name), " \t|,"):
+			return &ValidationError{Msg: fmt.Sprintf("invalid workflow status %q (no spaces, '|' or ',')", s.Name)}
+		case declared[s.Name]:
+			return &ValidationError{Msg: fmt.Sprintf("workflow status %q is declared twice", s.Name)}
+		}
+		switch s.Category {
+		case CategoryOpen, CategoryActive, CategoryDone, CategoryCancelled:
+		default:
+			return &ValidationError{Msg: fmt.Sprintf("invalid category %q of status %q (expected: open|active|done|cancelled)", s.Category, s.Name)}
+		}
+		declared[s.Name] = true
+	}
+
+	names := map[string]bool{}
+	for _, tr := range w.Transitions {
+		label := tr.Name
+		if label == "" {
+			label = "to " + string(tr.To)
+		}
+
+		if tr.Name != "" {
+			if names[tr.Name] {
+				return &ValidationError{Msg: fmt.Sprintf("workflow transition %q is declared twice", tr.Name)}
+			}
+			if slices.Contains(reserved, tr.Name) {
+				return &ValidationError{Msg: fmt.Sprintf("workflow transition %q is named like a command", tr.Name)}
+			}
+			names[tr.Name] = true
+		}
+		if !declared[tr.To] {
+			return &ValidationError{Msg: fmt.Sprintf("transition %s leads to undeclared status %q", label, tr.To)}
+		}
+		if len(tr.From) == 0 {
+			return &ValidationError{Msg: fmt.Sprintf("transition %s has no source statuses", label)}
+		}
+		for _, from := range tr.From {
+			if !declared[from] {
+				return &ValidationError{Msg: fmt.Sprintf("transition %s starts from undeclared status %q", label, from)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// StatusNames lists every status of the workflow in order
+func (w Workflow) StatusNames() []TaskStatus {
+	res := make([]TaskStatus, len(w.Statuses))
+	for i, s := range w.Statuses {
+		res[i] = s.Name
+	}
+	return res
+}
+
+// InitialStatus is the status of new tasks
+func (w Workflow) InitialStatus() TaskStatus {
+	return w.Statuses[0].Name
+}
+
+// Declares reports whether s is one of the workflow's statuses
+func (w Workflow) Declares(s TaskStatus) bool {
+	_, ok := w.Category(s)
+	return ok
+}
+
+// FindTransition looks up a transition of the workflow by name
+func (w Workflow) FindTransition(name string) (Transition, bool) {
+	for _, tr := range w.Transitions {
+		if name != "" && tr.Name == name {
+			return tr, true
+		}
+	}
+	return Transition{}, false
+}
+
+// TransitionTo finds the first transition of the workflow that leads from one status to another.
+// A task already in status to needs no transition; the returned one is then a no-op.
+// A task in a status the workflow does not declare, e.g. one dropped from the settings, may move to any declared one.
+func (w Workflow) TransitionTo(from, to TaskStatus) (Transition, error) {
+	if from == to {
+		return Transition{From: []TaskStatus{from}, To: to, Message: fmt.Sprintf("Task is already %s", to)}, nil
+	}
+	if !w.Declares(from) && w.Declares(to) {
+		return Transition{From: []TaskStatus{from}, To: to, Message: fmt.Sprintf("Task moved to %s", to)}, nil
+	}
+
+	var reachable []TaskStatus
+	for _, tr := range w.Transitions {
+		if !tr.Allows(from) {
+			continue
+		}
+		if tr.To == to {
+			return tr, nil
+		}
+		reachable = append(reachable, tr.To)
+	}
+
+	if len(reachable) == 0 {
+		return Transition{}, &ValidationError{Msg: fmt.Sprintf("a %s task cannot move to any other status", from)}
+	}
+	return Transition{}, &ValidationError{Msg: fmt.Sprintf("a %s task cannot move to %s (allowed: %s)", from, to, joinStatuses(reachable))}
+}
+
+// DoneTransition finds the first transition of the workflow that completes a task in status from
+func (w Workflow) DoneTransition(from TaskStatus) (Transition, bool) {
+	for _, tr := range w.Transitions {
+		if tr.Allows(from) && w.IsDone(tr.To) {
+			return tr, true
+		}
+	}
+	return Transition{}, false
+}
+
+// Category looks the status up in the workflow; ok is false for a status it does not declare
+func (w Workflow) Category(s TaskStatus) (c StatusCategory, ok bool) {
+	for _, def := range w.Statuses {
+		if def.Name == s {
+			return def.Category, true
+		}
+	}
+	return "", false
+}
+
+// IsClosed reports whether a task in status s needs no more work (done or cancelled).
+// Closed tasks do not block others, do not count as open subtasks and are never overdue.
+func (w Workflow) IsClosed(s TaskStatus) bool {
+	c, _ := w.Category(s)
+	return c == CategoryDone || c == CategoryCancelled
+}
+
+// IsDone reports whether status s means the work was completed
+func (w Workflow) IsDone(s TaskStatus) bool {
+	c, _ := w.Category(s)
+	return c == CategoryDone
+}
+
+// Allows reports whether the transition may start from status s
+func (tr Transition) Allows(s TaskStatus) bool {
+	for _, from := range tr.From {
+		if from == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTransition moves the task along tr of workflow w; applying a transition to a task already in its target status is a no-op.
+// openBlockers are the blockers that are not closed yet (see OpenBlockers); they prevent moving into an active status.
+// Pass nil to override them.
+func (t *Task) ApplyTransition(w Workflow, tr Transition, openBlockers []int) error {
+	if t.Status == tr.To {
+		return nil // idempotent
+	}
+	if !tr.Allows(t.Status) {
+		name := tr.Name
+		if name == "" {
+			name = "moving to " + string(tr.To)
+		}
+		return &ValidationError{Msg: fmt.Sprintf("task %d is %s; %s is only allowed from %s", t.ID, t.Status, name, joinStatuses(tr.From))}
+	}
+	if c, _ := w.Category(tr.To); c == CategoryActive && len(openBlockers) > 0 {
+		return &ValidationError{Msg: fmt.Sprintf("task %d is blocked by open tasks %s", t.ID, formatIDs(openBlockers))}
+	}
+
+	t.Status = tr.To
+	t.UpdatedAt = NowIso()
+	return nil
+}
+
+// applyNamed applies a built-in transition, which a custom workflow may not have
+func (t *Task) applyNamed(w Workflow, name string, openBlockers []int) error {
+	tr, ok := w.FindTransition(name)
+	if !ok {
+		return &ValidationError{Msg: fmt.Sprintf("the workflow has no %s transition", name)}
+	}
+	return t.ApplyTransition(w, tr, openBlockers)
+}
+
+// freeTransitions lets every status be reached from every other one
+func freeTransitions(statuses []StatusDef) []Transition {
+	res := make([]Transition, 0, len(statuses))
+	for _, to := range statuses {
+		var from []TaskStatus
+		for _, s := range statuses {
+			if s.Name != to.Name {
+				from = append(from, s.Name)
+			}
+		}
+		if len(from) > 0 {
+			res = append(res, Transition{From: from, To: to.Name})
+		}
+	}
+	return res
+}
+
+func (w Workflow) clone() Workflow {
+	c := Workflow{Statuses: append([]StatusDef(nil), w.Statuses...)}
+	for _, tr := range w.Transitions {
+		tr.From = append([]TaskStatus(nil), tr.From...)
+		c.Transitions = append(c.Transitions, tr)
+	}
+	return c
+}
+
+func joinStatuses(ss []TaskStatus) string {
+	parts := make([]string, len(ss))
+	for i, s := range ss {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ", ")
+}
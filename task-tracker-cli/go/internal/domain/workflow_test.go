@@ -0,0 +1,262 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyTransition(t *testing.T) {
+	tests := []struct {
+		from       TaskStatus
+		transition string
+		want       TaskStatus // "" when the transition is refused
+	}{
+		{StatusTodo, TransitionStart, StatusInProgress},
+		{StatusBlocked, TransitionStart, StatusInProgress},
+		{StatusDone, TransitionStart, ""},
+		{StatusInProgress, TransitionStart, StatusInProgress}, // idempotent
+		{StatusInProgress, TransitionReview, StatusInReview},
+		{StatusTodo, TransitionReview, ""},
+		{StatusInReview, TransitionDone, StatusDone},
+		{StatusCancelled, TransitionDone, ""},
+		{StatusInProgress, TransitionBlock, StatusBlocked},
+		{StatusTodo, TransitionCancel, StatusCancelled},
+		{StatusDone, TransitionCancel, ""},
+		{StatusDone, TransitionReopen, StatusTodo},
+		{StatusCancelled, TransitionReopen, StatusTodo},
+		{StatusInProgress, TransitionReopen, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" "+tt.transition, func(t *testing.T) {
+			w := DefaultWorkflow()
+			tr, ok := w.FindTransition(tt.transition)
+			if !ok {
+				t.Fatalf("transition %q missing from the table", tt.transition)
+			}
+			task := Task{ID: 1, Description: "A", Status: tt.from}
+
+			err := task.ApplyTransition(w, tr, nil)
+			if tt.want == "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				if task.Status != tt.from {
+					t.Errorf("status should remain %s, got %s", tt.from, task.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if task.Status != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, task.Status)
+			}
+		})
+	}
+}
+
+func TestTransitionTable_IsConsistent(t *testing.T) {
+	w := DefaultWorkflow()
+	seen := map[string]bool{}
+	for _, tr := range w.Transitions {
+		if seen[tr.Name] {
+			t.Errorf("duplicate transition %q", tr.Name)
+		}
+		seen[tr.Name] = true
+
+		if tr.Message == "" {
+			t.Errorf("transition %q has no message", tr.Name)
+		}
+		for _, st := range append(tr.From, tr.To) {
+			if _, err := ParseStatus(w, string(st)); err != nil {
+				t.Errorf("transition %q uses unknown status %q", tr.Name, st)
+			}
+		}
+	}
+}
+
+func TestIsClosed(t *testing.T) {
+	w := DefaultWorkflow()
+	for _, st := range w.StatusNames() {
+		want := st == StatusDone || st == StatusCancelled
+		if w.IsClosed(st) != want {
+			t.Errorf("%s: expected closed=%v", st, want)
+		}
+	}
+}
+
+// newWorkflow completes w, failing the test if it is invalid
+func newWorkflow(t *testing.T, w Workflow) Workflow {
+	t.Helper()
+	w, err := NewWorkflow(w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return w
+}
+
+func kanban() Workflow {
+	return Workflow{
+		Statuses: []StatusDef{
+			{Name: "backlog"},
+			{Name: "doing", Category: CategoryActive},
+			{Name: "shipped", Category: CategoryDone},
+			{Name: "dropped", Category: CategoryCancelled},
+		},
+		Transitions: []Transition{
+			{Name: "pick", From: []TaskStatus{"backlog"}, To: "doing"},
+			{Name: "ship", From: []TaskStatus{"doing"}, To: "shipped", Message: "Shipped"},
+			{From: []TaskStatus{"backlog", "doing"}, To: "dropped"},
+		},
+	}
+}
+
+func TestNewWorkflow(t *testing.T) {
+	w := newWorkflow(t, kanban())
+
+	task, err := NewTask(1, "A", w.InitialStatus())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status != "backlog" {
+		t.Errorf("new tasks should start in the first status, got %s", task.Status)
+	}
+	if _, err := ParseStatus(w, "todo"); err == nil || w.Declares(StatusTodo) {
+		t.Errorf("statuses of the default workflow should not parse")
+	}
+	if st, err := ParseStatus(w, "shipped"); err != nil || !w.IsDone(st) || !w.IsClosed(st) {
+		t.Errorf("shipped should parse as a done status, got %q, %v", st, err)
+	}
+	if w.IsDone("dropped") || !w.IsClosed("dropped") {
+		t.Errorf("dropped should be closed but not done")
+	}
+	if c, ok := w.Category("backlog"); !ok || c != CategoryOpen {
+		t.Errorf("backlog should default to open, got %q", c)
+	}
+	if _, ok := w.Category(StatusTodo); ok {
+		t.Errorf("an undeclared status should have no category")
+	}
+
+	pick, _ := w.FindTransition("pick")
+	if pick.Message != "Task moved to doing" {
+		t.Errorf("expected the default message, got %q", pick.Message)
+	}
+	if _, ok := w.FindTransition(TransitionDone); ok {
+		t.Errorf("built-in transitions should not exist in a custom workflow")
+	}
+
+	if err := task.MarkDone(w); err == nil {
+		t.Errorf("expected MarkDone to fail without a %s transition", TransitionDone)
+	}
+	if err := task.ApplyTransition(w, pick, []int{2}); err == nil {
+		t.Errorf("expected open blockers to prevent entering an active status")
+	}
+}
+
+func TestNewWorkflow_WithoutTransitionsAllowsAnyMove(t *testing.T) {
+	w := newWorkflow(t, Workflow{Statuses: []StatusDef{{Name: "new"}, {Name: "closed", Category: CategoryDone}}})
+
+	for _, move := range [][2]TaskStatus{{"new", "closed"}, {"closed", "new"}} {
+		if _, err := w.TransitionTo(move[0], move[1]); err != nil {
+			t.Errorf("%s -> %s: unexpected error: %v", move[0], move[1], err)
+		}
+	}
+}
+
+func TestNewWorkflow_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		w    Workflow
+	}{
+		{"no statuses", Workflow{}},
+		{"duplicate status", Workflow{Statuses: []StatusDef{{Name: "a"}, {Name: "a"}}}},
+		{"status with a space", Workflow{Statuses: []StatusDef{{Name: "in progress"}}}},
+		{"unknown category", Workflow{Statuses: []StatusDef{{Name: "a", Category: "finished"}}}},
+		{"undeclared target", Workflow{
+			Statuses:    []StatusDef{{Name: "a"}},
+			Transitions: []Transition{{Name: "go", From: []TaskStatus{"a"}, To: "b"}},
+		}},
+		{"undeclared source", Workflow{
+			Statuses:    []StatusDef{{Name: "a"}},
+			Transitions: []Transition{{Name: "go", From: []TaskStatus{"b"}, To: "a"}},
+		}},
+		{"no source", Workflow{
+			Statuses:    []StatusDef{{Name: "a"}},
+			Transitions: []Transition{{Name: "go", To: "a"}},
+		}},
+		{"duplicate transition", Workflow{
+			Statuses: []StatusDef{{Name: "a"}, {Name: "b"}},
+			Transitions: []Transition{
+				{Name: "go", From: []TaskStatus{"a"}, To: "b"},
+				{Name: "go", From: []TaskStatus{"b"}, To: "a"},
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWorkflow(tt.w)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+		})
+	}
+}
+
+func TestNewWorkflow_ReservedTransitionName(t *testing.T) {
+	w := Workflow{
+		Statuses:    []StatusDef{{Name: "a"}, {Name: "b"}},
+		Transitions: []Transition{{Name: "delete", From: []TaskStatus{"a"}, To: "b"}},
+	}
+	var validationErr *ValidationError
+	if _, err := NewWorkflow(w, "delete", "move"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+
+	w.Transitions[0].Name = "archive-it"
+	if _, err := NewWorkflow(w, "delete", "move"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTransitionTo(t *testing.T) {
+	w := newWorkflow(t, kanban())
+
+	tests := []struct {
+		from, to TaskStatus
+		wantErr  bool
+	}{
+		{"backlog", "doing", false},
+		{"doing", "shipped", false},
+		{"doing", "dropped", false},
+		{"backlog", "backlog", false}, // already there
+		{"backlog", "shipped", true},
+		{"shipped", "backlog", true}, // nothing leaves shipped
+		{"todo", "shipped", false},   // undeclared, e.g. left over from the previous workflow
+		{"todo", "done", true},       // only to a declared status
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			tr, err := w.TransitionTo(tt.from, tt.to)
+			if tt.wantErr {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected ValidationError, got %T: %v", err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			task := Task{ID: 1, Description: "A", Status: tt.from}
+			if err := task.ApplyTransition(w, tr, nil); err != nil || task.Status != tt.to {
+				t.Errorf("expected to reach %s, got %s, %v", tt.to, task.Status, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package ports
+
+import "encoding/json"
+
+// Journal persists the undo and redo stacks of the changes made to the task store.
+type Journal interface {
+	Load() (JournalStacks, error)
+
+	// Update loads the stacks, lets fn change them and saves them back if fn succeeds,
+	// excluding concurrent invocations for the whole cycle.
+	Update(fn func(stacks *JournalStacks) error) error
+}
+
+// JournalStacks holds the entries that can be undone and redone, most recent last.
+type JournalStacks struct {
+	Undo []JournalEntry `json:"undo"`
+	Redo []JournalEntry `json:"redo"`
+}
+
+// JournalEntry is one unit of work: the command that ran and every task it changed.
+// A barrier stands for a command whose changes are not journaled, such as moving tasks to the archive;
+// it has no changes and cannot be undone, nor can anything before it.
+type JournalEntry struct {
+	Command string       `json:"command"`
+	At      string       `json:"at"` // RFC3339 in UTC
+	Changes []TaskChange `json:"changes"`
+	Barrier bool         `json:"barrier,omitempty"`
+}
+
+// TaskChange is the state of one task before and after a unit of work.
+// Before is nil for a task that was created, After for one that was removed.
+type TaskChange struct {
+	ID     int        `json:"id"`
+	Before *TaskState `json:"before,omitempty"`
+	After  *TaskState `json:"after,omitempty"`
+}
+
+// TaskState is one side of a TaskChange, reduced to what it takes to put the task back in that state.
+// Digest identifies the whole task except its history and update time, so that a task changed since can be told apart.
+// Fields holds task fields in their JSON form, null for a cleared one: those that differ from the other side,
+// or all of them when there is no other side.
+type TaskState struct {
+	Digest string                     `json:"digest"`
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+}
@@ -0,0 +1,23 @@
+package ports
+
+// Migrator is implemented by repositories whose on-disk format is versioned.
+// With dryRun set nothing is written and the report describes what would change.
+type Migrator interface {
+	Migrate(dryRun bool) (MigrationReport, error)
+}
+
+// MigrationReport describes the upgrade of a store from one format version to another.
+type MigrationReport struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	Steps       []MigrationStep
+	Applied     bool
+}
+
+// MigrationStep is one upgrade from version From to From+1.
+type MigrationStep struct {
+	From        int
+	Description string
+	Changes     string
+}
@@ -7,3 +7,54 @@ type TaskRepository interface {
 	Load() ([]domain.Task, error)
 	Save([]domain.Task) error
 }
+
+// Locker is implemented by repositories that can serialize a read-modify-write
+// cycle across processes sharing the same store.
+type Locker interface {
+	WithLock(fn func() error) error
+}
+
+// Sequencer is implemented by whole-collection repositories that persist the highest ID
+// ever allocated, so IDs of deleted tasks are never handed out again.
+// LastID reports the value as of the last Load; SetLastID records a new value for the next Save.
+type Sequencer interface {
+	LastID() int
+	SetLastID(id int)
+}
+
+// ProjectCatalog is implemented by whole-collection repositories that persist the named projects with the tasks.
+// Projects reports them as of the last Load; SetProjects records the list for the next Save.
+type ProjectCatalog interface {
+	Projects() []domain.Project
+	SetProjects(projects []domain.Project)
+}
+
+// TaskStore abstracts per-task persistence so adapters can do targeted writes
+// instead of rewriting the whole collection.
+// Lookups of unknown IDs return *domain.NotFoundError.
+//
+// Update is optimistic: it succeeds only if task.Revision matches the stored revision,
+// then persists the task with the revision incremented. A stale revision yields *domain.ConflictError.
+type TaskStore interface {
+	Get(id int) (domain.Task, error)
+	List() ([]domain.Task, error)
+	ListByStatus(status domain.TaskStatus) ([]domain.Task, error)
+	Insert(task domain.Task) error
+	Update(task domain.Task) error
+	Delete(id int) error
+
+	// NextID allocates a task ID from a monotonic, persisted counter.
+	NextID() (int, error)
+
+	// Projects lists the named projects by name; the default project is implicit and never stored.
+	Projects() ([]domain.Project, error)
+	// SaveProject inserts a project or replaces the one with the same name.
+	SaveProject(p domain.Project) error
+	// DeleteProject removes a project, leaving its tasks as they are.
+	DeleteProject(name string) error
+
+	// Atomic runs fn as one unit of work: everything read and written through tx
+	// is isolated from concurrent invocations and committed only if fn succeeds.
+	// Calling Atomic on tx itself simply runs fn within the current unit.
+	Atomic(fn func(tx TaskStore) error) error
+}